@@ -1,24 +1,38 @@
 package main
 
 import (
+	"context"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"io"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/larryr/tools/gocoap"
+	"github.com/larryr/tools/gocoap/lwm2m"
+	"github.com/larryr/tools/gocoap/senml"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/options"
+	coapudp "github.com/plgd-dev/go-coap/v3/udp"
 )
 
 func usage() {
-	qfprintf(os.Stderr, "Usage: gocoap [options] <command> <url>\n\n")
+	qfprintf(os.Stderr, "Usage: gocoap [options] <command> <url|@profile/path>\n\n")
 	qfprintf(os.Stderr, "Commands:\n")
 	qfprintf(os.Stderr, "  get     Perform a GET request\n")
 	qfprintf(os.Stderr, "  put     Perform a PUT request\n")
 	qfprintf(os.Stderr, "  post    Perform a POST request\n")
 	qfprintf(os.Stderr, "  delete  Perform a DELETE request\n")
-	qfprintf(os.Stderr, "  observe Perform an observe request\n")
+	qfprintf(os.Stderr, "  observe Observe a resource until interrupted\n")
+	qfprintf(os.Stderr, "  ping    Send CoAP pings and report round-trip times until interrupted\n")
+	qfprintf(os.Stderr, "  lwm2m   Read LwM2M objects (default /0, /3 and /1) and print them as trees\n")
+	qfprintf(os.Stderr, "  serve   Serve recorded notifications: serve -replay <file> [-addr <host:port>]\n")
 	qfprintf(os.Stderr, "  block   block\n")
 	qfprintf(os.Stderr, "  example Execute the example\n")
 	qfprintf(os.Stderr, "  version Print version\n\n")
@@ -28,8 +42,15 @@ func usage() {
 	qfprintf(os.Stderr, "  -f <file>          File containing payload for PUT/POST requests\n")
 	qfprintf(os.Stderr, "  -n                 Use non-confirmable messages\n")
 	qfprintf(os.Stderr, "  -c <content-type>  Content format (coap content-type id)\n")
-	qfprintf(os.Stderr, "  -b <option>        block size option\n")
+	qfprintf(os.Stderr, "  -b <size>          block size for block-wise transfers (16..1024, default: 1024)\n")
+	qfprintf(os.Stderr, "  -o <file>          write the response payload to a file\n")
 	qfprintf(os.Stderr, "  -x                 print request time\n")
+	qfprintf(os.Stderr, "  -raw               print SenML payloads as received instead of as a table\n")
+	qfprintf(os.Stderr, "  -json              print one JSON object per response or notification\n")
+	qfprintf(os.Stderr, "  -record <file>     observe: record the notifications to a file for serve -replay\n")
+	qfprintf(os.Stderr, "  -count <n>         ping: stop after n pings (default: until interrupted)\n")
+	qfprintf(os.Stderr, "  -i <duration>      ping: interval between pings (default: 1s)\n")
+	qfprintf(os.Stderr, "  -config <file>     config file with named profiles (default: ~/.config/gocoap/config.yaml)\n")
 	qfprintf(os.Stderr, "  -v                 Verbose output\n")
 	qfprintf(os.Stderr, "  -q                 quiet: do not print status codes of received messages\n")
 	qfprintf(os.Stderr, "  -h                 Show this help message\n\n")
@@ -38,6 +59,13 @@ func usage() {
 	qfprintf(os.Stderr, "  gocoap put -p \"Hello, CoAP!\" coap://example.org:5683/test\n")
 	qfprintf(os.Stderr, "  gocoap post -f payload.txt -c json coap://example.org:5683/test\n")
 	qfprintf(os.Stderr, "  gocoap get -n -v coap://example.org:5683/test\n")
+	qfprintf(os.Stderr, "  gocoap -o firmware.bin -b 512 get coap://example.org:5683/fw\n")
+	qfprintf(os.Stderr, "  gocoap get @kitchen-sensor/temp\n")
+	qfprintf(os.Stderr, "  gocoap -json observe coap://example.org:5683/obs | jq .payload\n")
+	qfprintf(os.Stderr, "  gocoap -record session.jsonl observe coap://example.org:5683/obs\n")
+	qfprintf(os.Stderr, "  gocoap serve -replay session.jsonl -addr :5683\n")
+	qfprintf(os.Stderr, "  gocoap lwm2m coap://device/3/0\n")
+	qfprintf(os.Stderr, "  gocoap -count 5 -i 200ms ping coap://example.org\n")
 }
 
 func main() {
@@ -48,6 +76,15 @@ func main() {
 	nonConfirmable := flag.Bool("n", false, "use non-confirmable messages")
 	contentId := flag.Int("c", 0, "media type for requests (numeric code)")
 	verbose := flag.Bool("v", false, "verbose output")
+	raw := flag.Bool("raw", false, "print SenML payloads as received")
+	jsonOut := flag.Bool("json", false, "print one JSON object per response or notification")
+	recordFile := flag.String("record", "", "record observe notifications to a file")
+	blockSize := flag.Int("b", 1024, "block size for block-wise transfers")
+	outputFile := flag.String("o", "", "write the response payload to a file")
+	quiet := flag.Bool("q", false, "quiet: do not print status codes or progress")
+	configFile := flag.String("config", "", "config file with named profiles")
+	count := flag.Int("count", 0, "ping: number of pings, 0 until interrupted")
+	interval := flag.Duration("i", time.Second, "ping: interval between pings")
 
 	// Custom usage function
 	flag.Usage = usage
@@ -69,6 +106,14 @@ func main() {
 		os.Exit(0)
 	}
 
+	if command == "serve" {
+		if err := serve(flag.Args()[1:]); err != nil {
+			qfprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	if flag.NArg() < 2 {
 		qfprintf(os.Stderr, "Error: URL is required\n")
 		usage()
@@ -76,8 +121,48 @@ func main() {
 	}
 	url := flag.Arg(1)
 
+	// A @profile/path target takes the base URL and settings from the config file.
+	// Flags given on the command line take precedence over the settings of the profile.
+	var clientOpts []gocoap.Option
+	if strings.HasPrefix(url, "@") {
+		profile, expanded, err := loadProfile(*configFile, url)
+		if err != nil {
+			qfprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		url = expanded
+		given := map[string]bool{}
+		flag.Visit(func(f *flag.Flag) { given[f.Name] = true })
+		if profile.Timeout > 0 && !given["t"] {
+			*timeout = profile.Timeout
+		}
+		if profile.ContentFormat != nil && !given["c"] {
+			*contentId = *profile.ContentFormat
+		}
+		if profile.BlockSize != 0 && !given["b"] {
+			*blockSize = profile.BlockSize
+		}
+		// the block size is validated below together with the -b flag
+		settings := *profile
+		settings.BlockSize = 0
+		opts, err := settings.ClientOptions()
+		if err != nil {
+			qfprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		clientOpts = append(clientOpts, opts...)
+	}
+
+	// validate block size
+	szx, err := gocoap.ValidateBlockSize(*blockSize)
+	if err != nil {
+		qfprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(2)
+	}
+	clientOpts = append(clientOpts, gocoap.WithBlockSize(szx))
+
 	// Create a new client
-	client := gocoap.NewClient(*timeout)
+	client := gocoap.NewClient(*timeout, clientOpts...)
 
 	// validate media type
 	ct, err := gocoap.ValidateContentType(*contentId)
@@ -115,34 +200,388 @@ func main() {
 		if *payloadFile != "" {
 			qfprintf(os.Stderr, "  Payload File: %s\n", *payloadFile)
 		}
+		qfprintf(os.Stderr, "  Block Size: %d\n", *blockSize)
+		if *outputFile != "" {
+			qfprintf(os.Stderr, "  Output File: %s\n", *outputFile)
+		}
 		qfprintf(os.Stderr, "\n")
 	}
 
+	// Progress is only reported for transfers from or to files
+	var uploadProgress, downloadProgress gocoap.ProgressFunc
+	if !*quiet && *payloadFile != "" {
+		uploadProgress = printProgress
+	}
+	if !*quiet && *outputFile != "" {
+		downloadProgress = printProgress
+	}
+
+	// The output file is only replaced once the request succeeded
+	var output *outputWriter
+	if *outputFile != "" && command == "get" {
+		output, err = newOutputWriter(*outputFile)
+		if err != nil {
+			qfprintf(os.Stderr, "Error creating output file: %v\n", err)
+			os.Exit(3)
+		}
+	}
+
 	// Execute the command
-	var response []byte
+	var response *gocoap.Response
 	switch command {
+	case "lwm2m":
+		if err := browseLwM2M(*timeout, clientOpts, url); err != nil {
+			qfprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	case "ping":
+		if !ping(client, url, *count, *interval) {
+			os.Exit(1)
+		}
+		return
+	case "observe":
+		var recorder *gocoap.Recorder
+		if *recordFile != "" {
+			file, err := os.Create(*recordFile)
+			if err != nil {
+				qfprintf(os.Stderr, "Error creating record file: %v\n", err)
+				os.Exit(3)
+			}
+			defer func() { _ = file.Close() }()
+			if recorder, err = gocoap.NewRecorder(file, url); err != nil {
+				qfprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer stop()
+		err = client.Observe(ctx, url, func(resp *gocoap.Response) {
+			if !*quiet && !*jsonOut {
+				qfprintf(os.Stderr, "%v\n", resp.Code)
+			}
+			if recorder != nil {
+				if err := recorder.Record(resp); err != nil {
+					qfprintf(os.Stderr, "Error: %v\n", err)
+				}
+			}
+			if err := printResponse(resp, *jsonOut, *raw); err != nil {
+				qfprintf(os.Stderr, "Error: %v\n", err)
+			}
+		})
+		if err != nil {
+			qfprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
 	case "get":
-		response, err = client.Get(url)
+		if output != nil {
+			response, err = client.Download(url, output, downloadProgress)
+		} else {
+			response, err = client.Request(codes.GET, url, ct, nil)
+		}
 	case "put":
-		response, err = client.Put(url, ct, payloadReader)
+		response, err = client.Upload(codes.PUT, url, ct, payloadReader, uploadProgress)
 	case "post":
-		response, err = client.Post(url, ct, payloadReader)
+		response, err = client.Upload(codes.POST, url, ct, payloadReader, uploadProgress)
 	case "delete":
-		response, err = client.Delete(url)
+		response, err = client.Request(codes.DELETE, url, ct, nil)
 	default:
 		qfprintf(os.Stderr, "Error: Unknown command '%s'\n", command)
 		usage()
 		os.Exit(1)
 	}
 
+	if uploadProgress != nil || downloadProgress != nil {
+		qfprintf(os.Stderr, "\n")
+	}
+
 	// Handle errors
 	if err != nil {
+		output.abort()
 		qfprintf(os.Stderr, "Error: %v\n", err)
 		os.Exit(1)
 	}
 
+	if !*quiet && !*jsonOut {
+		qfprintf(os.Stderr, "%v\n", response.Code)
+	}
+
+	// Write the response to the output file; a download has already been written
+	if *outputFile != "" {
+		if !response.IsSuccess() {
+			output.abort()
+			if *jsonOut {
+				_ = printResponse(response, true, *raw)
+			}
+			qfprintf(os.Stderr, "Error: %v %s\n", response.Code, response.Body)
+			os.Exit(1)
+		}
+		if output == nil {
+			if output, err = newOutputWriter(*outputFile); err != nil {
+				qfprintf(os.Stderr, "Error creating output file: %v\n", err)
+				os.Exit(3)
+			}
+			if _, err := output.Write(response.Body); err != nil {
+				output.abort()
+				qfprintf(os.Stderr, "Error writing output file: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if err := output.commit(); err != nil {
+			qfprintf(os.Stderr, "Error writing output file: %v\n", err)
+			os.Exit(1)
+		}
+		if *jsonOut {
+			// the payload went to the file
+			response.Body = nil
+			_ = printResponse(response, true, *raw)
+		}
+		return
+	}
+
 	// Print the response
-	fmt.Println(string(response))
+	if err := printResponse(response, *jsonOut, *raw); err != nil {
+		qfprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// outputWriter writes the -o file to a temporary file next to it, which replaces the file on commit.
+// A failed request leaves a previous file untouched.
+type outputWriter struct {
+	*os.File
+	path string
+}
+
+func newOutputWriter(path string) (*outputWriter, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return nil, err
+	}
+	// keep the mode of the file that is replaced, temporary files are only readable by their owner
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+	if err := f.Chmod(mode); err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, err
+	}
+	return &outputWriter{File: f, path: path}, nil
+}
+
+// commit closes the temporary file and renames it to the output file.
+func (w *outputWriter) commit() error {
+	if err := w.Close(); err != nil {
+		_ = os.Remove(w.Name())
+		return err
+	}
+	if err := os.Rename(w.Name(), w.path); err != nil {
+		_ = os.Remove(w.Name())
+		return err
+	}
+	return nil
+}
+
+// abort removes the temporary file. It does nothing on a nil writer.
+func (w *outputWriter) abort() {
+	if w == nil {
+		return
+	}
+	_ = w.Close()
+	_ = os.Remove(w.Name())
+}
+
+// printResponse writes a response to stdout as a JSON object, a SenML table or the plain payload.
+func printResponse(resp *gocoap.Response, jsonOut, raw bool) error {
+	switch {
+	case jsonOut:
+		return json.NewEncoder(os.Stdout).Encode(resp)
+	case resp.IsSenML() && !raw:
+		records, err := resp.SenML()
+		if err != nil {
+			return err
+		}
+		return senml.WriteTable(os.Stdout, records)
+	}
+	_, err := fmt.Println(string(resp.Body))
+	return err
+}
+
+// browseLwM2M reads LwM2M objects, or the object, instance or resource named by the path
+// of url, and prints them as trees. Without a path the Security, Device and Server objects are read.
+func browseLwM2M(timeout time.Duration, opts []gocoap.Option, url string) error {
+	uri, err := gocoap.ParseURI(url)
+	if err != nil {
+		return err
+	}
+	paths := []lwm2m.Path{{0}, {3}, {1}}
+	if len(uri.Path) > 0 {
+		p, err := lwm2m.ParsePath(uri.PathString())
+		if err != nil {
+			return err
+		}
+		paths = []lwm2m.Path{p}
+	}
+
+	accept, err := gocoap.ParseHeaders(map[string]string{"accept": strconv.Itoa(int(lwm2m.ContentFormatTLV))})
+	if err != nil {
+		return err
+	}
+	client := gocoap.NewClient(timeout, append(opts, gocoap.WithDefaultOptions(accept...))...)
+	reg := lwm2m.DefaultRegistry()
+	read := 0
+	for _, p := range paths {
+		target := *uri
+		target.Path = strings.Split(strings.TrimPrefix(p.String(), "/"), "/")
+		resp, err := client.Request(codes.GET, target.String(), 0, nil)
+		if err != nil {
+			return err
+		}
+		if !resp.IsSuccess() {
+			qfprintf(os.Stderr, "%s: %v\n", p, resp.Code)
+			continue
+		}
+		cf := lwm2m.ContentFormatTLV
+		if resp.HasContentFormat {
+			cf = resp.ContentFormat
+		}
+		resources, err := lwm2m.Decode(resp.Body, cf, p, reg)
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %v", p, err)
+		}
+		if err := lwm2m.WriteTree(os.Stdout, resources, reg); err != nil {
+			return err
+		}
+		read++
+	}
+	if read == 0 {
+		return fmt.Errorf("no object could be read")
+	}
+	return nil
+}
+
+// ping pings the host of url every interval, printing each round-trip time and, when count
+// pings were sent or the command is interrupted, the statistics. It reports whether any
+// ping was answered.
+func ping(client *gocoap.Client, url string, count int, interval time.Duration) bool {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	var stats gocoap.PingStats
+	qfprintf(os.Stdout, "PING %s\n", url)
+	for seq := 1; count == 0 || seq <= count; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+		rtt, err := client.Ping(url)
+		stats.Add(rtt, err)
+		if err != nil {
+			qfprintf(os.Stdout, "seq=%d: %v\n", seq, err)
+			continue
+		}
+		qfprintf(os.Stdout, "reset: seq=%d time=%s ms\n", seq, millis(rtt))
+	}
+
+	qfprintf(os.Stdout, "--- %s ping statistics ---\n", url)
+	qfprintf(os.Stdout, "%d pings sent, %d resets received, %.1f%% loss\n", stats.Sent, stats.Received(), stats.Loss())
+	if stats.Received() > 0 {
+		min, avg, max, stddev := stats.Summary()
+		qfprintf(os.Stdout, "rtt min/avg/max/stddev = %s/%s/%s/%s ms\n", millis(min), millis(avg), millis(max), millis(stddev))
+	}
+	return stats.Received() > 0
+}
+
+// millis formats a duration in milliseconds with microsecond precision.
+func millis(d time.Duration) string {
+	return strconv.FormatFloat(float64(d)/float64(time.Millisecond), 'f', 3, 64)
+}
+
+// serve runs the serve command, which replays a recorded observe session until interrupted.
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	replayFile := fs.String("replay", "", "recording made with observe -record")
+	addr := fs.String("addr", ":5683", "UDP address to listen on")
+	_ = fs.Parse(args)
+	if *replayFile == "" {
+		return fmt.Errorf("serve requires -replay <file>")
+	}
+
+	file, err := os.Open(*replayFile)
+	if err != nil {
+		return fmt.Errorf("failed to open recording: %v", err)
+	}
+	notifications, err := gocoap.ReadRecording(file)
+	_ = file.Close()
+	if err != nil {
+		return err
+	}
+	if len(notifications) == 0 {
+		return fmt.Errorf("recording %s is empty", *replayFile)
+	}
+	router, err := gocoap.NewReplayRouter(notifications)
+	if err != nil {
+		return err
+	}
+
+	l, err := coapnet.NewListenUDP("udp", *addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %v", err)
+	}
+	s := coapudp.NewServer(options.WithMux(router))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		s.Stop()
+	}()
+	qfprintf(os.Stderr, "Replaying %d notifications on %s\n", len(notifications), l.LocalAddr())
+	err = s.Serve(l)
+	_ = l.Close()
+	if ctx.Err() != nil {
+		return nil
+	}
+	return err
+}
+
+// loadProfile reads the config file, or the default one if path is empty,
+// and expands a @profile/path target.
+func loadProfile(path, target string) (*gocoap.Profile, string, error) {
+	if path == "" {
+		var err error
+		if path, err = gocoap.DefaultConfigPath(); err != nil {
+			return nil, "", err
+		}
+		if path == "" {
+			return nil, "", fmt.Errorf("no config file found for %s", target)
+		}
+	}
+	cfg, err := gocoap.LoadConfig(path)
+	if err != nil {
+		return nil, "", err
+	}
+	url, profile, err := cfg.Expand(target)
+	if err != nil {
+		return nil, "", err
+	}
+	return profile, url, nil
+}
+
+// printProgress reports transfer progress on a single stderr line.
+func printProgress(done, total int64) {
+	if total > 0 {
+		qfprintf(os.Stderr, "\r%d/%d bytes (%d%%)", done, total, done*100/total)
+	} else {
+		qfprintf(os.Stderr, "\r%d bytes", done)
+	}
 }
 
 func qfprintf(wr io.Writer, format string, a ...interface{}) {
@@ -6,11 +6,13 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/larryr/tools/gopuml"
+	"github.com/spf13/afero"
 )
 
 //RenderingOptionSlice will implements the sort interface
@@ -46,13 +48,90 @@ func main() {
 	title := flag.String("title", "", "Title of the generated diagram")
 	notes := flag.String("notes", "", "Comma separated list of notes to be added to the diagram")
 	output := flag.String("output", "", "output file path. If omitted, then this will default to standard output")
+	showTags := flag.Bool("show-tags", false, "Shows the struct tags of the fields, e.g. `json:\"name\"`")
+	showEnums := flag.Bool("show-enums", false, "Shows the named types of typed const groups as enums of their constants and values")
+	showUtilities := flag.Bool("show-utilities", false, "Shows the package level functions, variables and constants of each package in a utility class, connected to the types the functions create")
 	showOptionsAsNote := flag.Bool("show-options-as-note", false, "Show a note in the diagram with the none evident options ran with this CLI")
 	aggregatePrivateMembers := flag.Bool("aggregate-private-members", false, "Show aggregations for private members. Ignored if -show-aggregations is not used.")
-	styleFlag := flag.String("style", "", "URL for style/skinparams include file")
+	styleFlag := flag.String("style", "", "URL for style/skinparams include file, or default for the default -theme")
+	themeFlag := flag.String("theme", "", fmt.Sprintf("bundled theme inlined in the diagram: %s", strings.Join(gopuml.Themes(), ", ")))
+	direction := flag.String("direction", "left-to-right", "layout direction: left-to-right or top-to-bottom")
+	grouping := flag.String("grouping", "namespace", "how the types of a package are grouped: namespace, package, folder, frame, rectangle, cloud, node or database")
+	hiddenLinks := flag.String("hidden-links", "", "comma separated list of From->To types laid out as if they were connected, e.g. 'nclient4.Client->nclient4.Matcher'")
+	tags := flag.String("tags", "", "comma separated list of build tags, as for go build")
+	goos := flag.String("goos", "", "GOOS to select files for (default: the running platform)")
+	goarch := flag.String("goarch", "", "GOARCH to select files for (default: the running platform)")
+	namespacePrefix := flag.String("namespace-prefix", "", "import path prefix removed from the package namespaces, e.g. the module path")
+	focus := flag.String("focus", "", "renders only the types within -depth relations of a type, e.g. nclient4.Client")
+	depth := flag.Int("depth", 1, "number of compositions, implementations, aggregations or aliases a type can be away from the -focus type")
+	include := flag.String("include", "", "comma separated list of regular expressions. Only the types whose package qualified name matches one are rendered")
+	exclude := flag.String("exclude", "", "comma separated list of regular expressions of package qualified type names that are not rendered")
+	docs := flag.String("docs", "", "renders the doc comments of the types as notes: summary for the first sentence, or full")
+	methodDocs := flag.Bool("method-docs", false, "links the types and methods to pkg.go.dev with their doc comment as the tooltip of SVG output")
+	format := flag.String("format", gopuml.FormatPlantUML, "output format of class diagrams: plantuml, mermaid, dot, d2, or json for the parsed model that -model renders")
+	mode := flag.String("mode", "classes", "diagram to render: classes, or packages for a component diagram of the imports between the packages")
+	externalPackages := flag.Bool("external-packages", false, "renders the imported packages outside of the directories in -mode=packages")
+	highlightCycles := flag.Bool("highlight-cycles", false, "highlights import cycles in -mode=packages")
+	sequence := flag.String("sequence", "", "renders the sequence diagram of the calls made by a function instead, e.g. 'nclient4.(*Client).Request'. Implies -types")
+	sequenceDepth := flag.Int("sequence-depth", 0, "how deep calls are followed in -sequence diagrams (default: no limit)")
+	sequencePackages := flag.String("sequence-packages", "", "comma separated list of regular expressions of other import paths whose calls are shown in -sequence diagrams")
+	sequenceExclude := flag.String("sequence-exclude", "", "comma separated list of regular expressions of import paths whose calls are hidden in -sequence diagrams")
+	diff := flag.String("diff", "", "renders the changes between two git revisions, e.g. main..HEAD, or between a revision and the working tree, e.g. main: added types and members are green, removed ones red and changed ones orange")
+	url := flag.Bool("url", false, "writes the URL of the diagram image on the -server instead of the PlantUML text")
+	server := flag.String("server", gopuml.DefaultPlantUMLServer, "PlantUML server of the -url")
+	urlFormat := flag.String("url-format", "svg", "image the -url renders: svg, png, txt, or uml for the editor of the server")
+	renderCmd := flag.String("render-cmd", "", "command the diagram is piped to, writing its output instead, e.g. 'plantuml -tsvg -pipe' or 'dot -Tpng' with -format dot")
+	report := flag.String("report", "", "writes a report instead of a diagram: implementations, the types implementing each interface")
+	reportFormat := flag.String("report-format", gopuml.ReportText, "format of the -report: text or json")
+	requiredImplementations := flag.String("require-implementations", "", "file listing interfaces, one per line, that must keep implementations. gopuml fails when one has none")
+	model := flag.String("model", "", "renders the JSON model of a -format json run instead of parsing directories")
+	typeChecked := flag.Bool("types", false, "resolve implementations, aliases and imports with go/types. Falls back to parsing when the packages do not load")
 
 	flag.Parse()
+	if *mode != "classes" && *mode != "packages" {
+		fmt.Fprintf(os.Stderr, "unknown mode %s, expected classes or packages\n", *mode)
+		os.Exit(1)
+	}
+	renderer, err := gopuml.NewRenderer(*format)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+	if *format != gopuml.FormatPlantUML && (*mode != "classes" || *sequence != "") {
+		fmt.Fprintf(os.Stderr, "-format %s only applies to class diagrams\n", *format)
+		os.Exit(1)
+	}
+	if *diff != "" && (*format != gopuml.FormatPlantUML || *mode != "classes" || *sequence != "" || *typeChecked) {
+		fmt.Fprintln(os.Stderr, "-diff only applies to PlantUML class diagrams, without -types")
+		os.Exit(1)
+	}
 
-	style := manageStyle(styleFlag)
+	if *model != "" && (*sequence != "" || *diff != "" || *typeChecked) {
+		fmt.Fprintln(os.Stderr, "-model cannot be used with -sequence, -diff or -types")
+		os.Exit(1)
+	}
+
+	if *report != "" && *report != "implementations" {
+		fmt.Fprintf(os.Stderr, "unknown report %s, expected implementations\n", *report)
+		os.Exit(1)
+	}
+	if *report != "" && (*mode != "classes" || *sequence != "" || *diff != "" || *url || *renderCmd != "") {
+		fmt.Fprintln(os.Stderr, "-report cannot be used with -mode=packages, -sequence, -diff, -url or -render-cmd")
+		os.Exit(1)
+	}
+	if *url && *format != gopuml.FormatPlantUML {
+		fmt.Fprintln(os.Stderr, "-url only applies to PlantUML diagrams")
+		os.Exit(1)
+	}
+	if *url && *renderCmd != "" {
+		fmt.Fprintln(os.Stderr, "-url and -render-cmd cannot be used together")
+		os.Exit(1)
+	}
+
+	style, styleTheme := manageStyle(styleFlag)
+	if *themeFlag == "" {
+		*themeFlag = styleTheme
+	}
 
 	renderingOptions := map[gopuml.RenderingOption]interface{}{
 		gopuml.RenderConnectionLabels:  *showConnectionLabels,
@@ -62,6 +141,22 @@ func main() {
 		gopuml.RenderTitle:             *title,
 		gopuml.AggregatePrivateMembers: *aggregatePrivateMembers,
 		gopuml.RenderStyle:             style,
+		gopuml.RenderNamespacePrefix:   *namespacePrefix,
+		gopuml.RenderExternalPackages:  *externalPackages,
+		gopuml.RenderPackageCycles:     *highlightCycles,
+		gopuml.RenderFocus:             *focus,
+		gopuml.RenderFocusDepth:        *depth,
+		gopuml.RenderInclude:           getList(*include),
+		gopuml.RenderExclude:           getList(*exclude),
+		gopuml.RenderDocs:              *docs,
+		gopuml.RenderMethodDocs:        *methodDocs,
+		gopuml.RenderTags:              *showTags,
+		gopuml.RenderEnums:             *showEnums,
+		gopuml.RenderUtilities:         *showUtilities,
+		gopuml.RenderTheme:             *themeFlag,
+		gopuml.RenderDirection:         strings.Replace(*direction, "-", " ", -1),
+		gopuml.RenderGrouping:          *grouping,
+		gopuml.RenderHiddenLinks:       getList(*hiddenLinks),
 	}
 	if *hideConnections {
 		renderingOptions[gopuml.RenderAliases] = *showAliases
@@ -89,28 +184,86 @@ func main() {
 		}
 	}
 	renderingOptions[gopuml.RenderNotes] = strings.Join(noteList, "\n")
-	dirs, err := getDirectories()
+	var result *gopuml.ClassParser
+	if *model != "" {
+		result, err = loadModel(*model, renderingOptions)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	} else {
+		dirs, recursiveDirs, err := getDirectories()
 
-	if err != nil {
-		fmt.Println("usage:\ngoplantuml <DIR>\nDIR Must be a valid directory")
-		fmt.Fprintln(os.Stderr, err.Error())
-		os.Exit(1)
-	}
-	ignoredDirectories, err := getIgnoredDirectories(*ignore)
-	if err != nil {
+		if err != nil {
+			fmt.Println("usage:\ngoplantuml <DIR>\nDIR Must be a valid directory")
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		ignoredDirectories, err := getIgnoredDirectories(*ignore)
+		if err != nil {
 
-		fmt.Println("usage:\ngoplantuml [-ignore=<DIRLIST>]\nDIRLIST Must be a valid comma separated list of existing directories")
-		fmt.Fprintln(os.Stderr, err.Error())
-		os.Exit(1)
-	}
+			fmt.Println("usage:\ngoplantuml [-ignore=<DIRLIST>]\nDIRLIST Must be a valid comma separated list of existing directories")
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
 
-	result, err := gopuml.NewClassDiagram(dirs, ignoredDirectories, *recursive)
-	result.SetRenderingOptions(renderingOptions)
-	if err != nil {
-		fmt.Fprintln(os.Stderr, err.Error())
-		os.Exit(1)
+		options := &gopuml.ClassDiagramOptions{
+			FileSystem:         afero.NewOsFs(),
+			Directories:        dirs,
+			IgnoredDirectories: ignoredDirectories,
+			Recursive:          *recursive || recursiveDirs,
+			RenderingOptions:   renderingOptions,
+			TypeChecked:        *typeChecked || *sequence != "",
+			BuildTags:          getList(*tags),
+			GOOS:               *goos,
+			GOARCH:             *goarch,
+		}
+		if *diff != "" {
+			result, err = diffDiagram(options, *diff)
+		} else {
+			result, err = gopuml.NewClassDiagramWithOptions(options)
+		}
+		if err != nil && *typeChecked && *sequence == "" {
+			fmt.Fprintf(os.Stderr, "type checking failed, falling back to parsing: %v\n", err)
+			options.TypeChecked = false
+			result, err = gopuml.NewClassDiagramWithOptions(options)
+		}
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
+	var rendered string
+	if *report != "" {
+		rendered, err = result.RenderImplementations(*reportFormat)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	} else if *sequence != "" {
+		rendered, err = result.RenderSequence(&gopuml.SequenceOptions{
+			EntryPoint:       *sequence,
+			MaxDepth:         *sequenceDepth,
+			Packages:         getList(*sequencePackages),
+			ExcludedPackages: getList(*sequenceExclude),
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	} else if *mode == "packages" {
+		rendered = result.RenderPackages()
+	} else {
+		rendered = renderer.Render(result)
+	}
+	if *url {
+		rendered, err = gopuml.PlantUMLURL(*server, *urlFormat, rendered)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+		rendered += "\n"
 	}
-	rendered := result.Render()
 	var writer io.Writer
 	if *output != "" {
 		writer, err = os.Create(*output)
@@ -120,31 +273,159 @@ func main() {
 	} else {
 		writer = os.Stdout
 	}
-	fmt.Fprint(writer, rendered)
+	if *renderCmd != "" {
+		if err := runRenderCommand(*renderCmd, rendered, writer); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	} else {
+		fmt.Fprint(writer, rendered)
+	}
+	if *requiredImplementations != "" {
+		if err := checkImplementations(result, *requiredImplementations); err != nil {
+			fmt.Fprintln(os.Stderr, err.Error())
+			os.Exit(1)
+		}
+	}
 }
 
-func getDirectories() ([]string, error) {
+//loadModel returns the parser of the JSON model in a file
+func loadModel(path string, renderingOptions map[gopuml.RenderingOption]interface{}) (*gopuml.ClassParser, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open the model: %v", err)
+	}
+	defer f.Close()
+	model, err := gopuml.ReadModel(f)
+	if err != nil {
+		return nil, err
+	}
+	return gopuml.NewClassDiagramFromModel(model, renderingOptions)
+}
+
+//checkImplementations fails when an interface listed in the file has no implementations
+func checkImplementations(result *gopuml.ClassParser, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open the required interfaces: %v", err)
+	}
+	defer f.Close()
+	required, err := gopuml.ReadRequiredInterfaces(f)
+	if err != nil {
+		return err
+	}
+	return result.CheckImplementations(required)
+}
+
+//runRenderCommand pipes the diagram to a command, e.g. plantuml -tsvg -pipe, and writes its output. The
+//command is split on spaces, it does not run in a shell.
+func runRenderCommand(command, diagram string, writer io.Writer) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return errors.New("empty render command")
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = strings.NewReader(diagram)
+	cmd.Stdout = writer
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %v", command, err)
+	}
+	return nil
+}
+
+//getDirectories returns the directories of the arguments, and true if one of them is a dir/... pattern that
+//is parsed recursively
+func getDirectories() ([]string, bool, error) {
 
 	args := flag.Args()
 	if len(args) < 1 {
-		return nil, errors.New("DIR missing")
+		return nil, false, errors.New("DIR missing")
 	}
 	dirs := []string{}
+	recursive := false
 	for _, dir := range args {
+		if dir == "..." || strings.HasSuffix(dir, "/...") {
+			dir = strings.TrimSuffix(strings.TrimSuffix(dir, "..."), "/")
+			if dir == "" {
+				dir = "."
+			}
+			recursive = true
+		}
 		fi, err := os.Stat(dir)
 		if os.IsNotExist(err) {
-			return nil, fmt.Errorf("could not find directory %s", dir)
+			return nil, false, fmt.Errorf("could not find directory %s", dir)
 		}
 		if !fi.Mode().IsDir() {
-			return nil, fmt.Errorf("%s is not a directory", dir)
+			return nil, false, fmt.Errorf("%s is not a directory", dir)
 		}
 		dirAbs, err := filepath.Abs(dir)
 		if err != nil {
-			return nil, fmt.Errorf("could not find directory %s", dir)
+			return nil, false, fmt.Errorf("could not find directory %s", dir)
 		}
 		dirs = append(dirs, dirAbs)
 	}
-	return dirs, nil
+	return dirs, recursive, nil
+}
+
+//diffDiagram parses the directories at the two revisions of a range, old..new, or at a revision and in the
+//working tree, and merges the older diagram into the newer one
+func diffDiagram(options *gopuml.ClassDiagramOptions, revisions string) (*gopuml.ClassParser, error) {
+	if strings.Contains(revisions, "...") {
+		return nil, fmt.Errorf("unsupported revision range %s, expected old..new or a revision", revisions)
+	}
+	oldRevision, newRevision := revisions, ""
+	if i := strings.Index(revisions, ".."); i >= 0 {
+		oldRevision, newRevision = revisions[:i], revisions[i+2:]
+		if newRevision == "" {
+			newRevision = "HEAD"
+		}
+	}
+	if oldRevision == "" {
+		return nil, fmt.Errorf("missing the older revision of %s", revisions)
+	}
+	// the files of a revision are at the paths of the repository, without symbolic links
+	resolved := *options
+	resolved.Directories = nil
+	for _, dir := range options.Directories {
+		dir, err := filepath.EvalSymlinks(dir)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve directory %s: %v", dir, err)
+		}
+		resolved.Directories = append(resolved.Directories, dir)
+	}
+	newDiagram, err := parseRevision(resolved, newRevision)
+	if err != nil {
+		return nil, err
+	}
+	resolved.RenderingOptions = map[gopuml.RenderingOption]interface{}{}
+	oldDiagram, err := parseRevision(resolved, oldRevision)
+	if err != nil {
+		return nil, err
+	}
+	newDiagram.Diff(oldDiagram)
+	return newDiagram, nil
+}
+
+//parseRevision parses the directories that exist at a git revision, or in the working tree when the
+//revision is empty
+func parseRevision(options gopuml.ClassDiagramOptions, revision string) (*gopuml.ClassParser, error) {
+	if revision == "" {
+		return gopuml.NewClassDiagramWithOptions(&options)
+	}
+	fs, err := gopuml.NewGitFs(options.Directories[0], revision)
+	if err != nil {
+		return nil, err
+	}
+	options.FileSystem = fs
+	var dirs []string
+	for _, dir := range options.Directories {
+		if exists, _ := afero.DirExists(fs, dir); exists {
+			dirs = append(dirs, dir)
+		}
+	}
+	options.Directories = dirs
+	return gopuml.NewClassDiagramWithOptions(&options)
 }
 
 func getIgnoredDirectories(list string) ([]string, error) {
@@ -164,6 +445,16 @@ func getIgnoredDirectories(list string) ([]string, error) {
 	return result, nil
 }
 
+func getList(list string) []string {
+	var result []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
 func getLegend(ro map[gopuml.RenderingOption]interface{}) (string, error) {
 	result := "<u><b>Legend</b></u>\n"
 	orderedOptions := RenderingOptionSlice{}
@@ -188,21 +479,25 @@ func getLegend(ro map[gopuml.RenderingOption]interface{}) (string, error) {
 			result = fmt.Sprintf("%sRender Methods: %t\n", result, val.(bool))
 		case gopuml.AggregatePrivateMembers:
 			result = fmt.Sprintf("%sPritave Aggregations: %t\n", result, val.(bool))
+		case gopuml.RenderTags:
+			result = fmt.Sprintf("%sRender Tags: %t\n", result, val.(bool))
+		case gopuml.RenderEnums:
+			result = fmt.Sprintf("%sRender Enums: %t\n", result, val.(bool))
+		case gopuml.RenderUtilities:
+			result = fmt.Sprintf("%sRender Utilities: %t\n", result, val.(bool))
 		}
 	}
 	return strings.TrimSpace(result), nil
 }
 
-const defaultStyleFlag = "https://raw.githubusercontent.com/larryr/UsePlantUML/master/style/class.puml"
-
-// check style flag for wellknown values
-func manageStyle(flag *string) string {
+// check style flag for wellknown values, default selects the bundled default theme instead of an include
+func manageStyle(flag *string) (string, string) {
 
 	switch *flag {
 	case "default":
-		return defaultStyleFlag
+		return "", gopuml.DefaultTheme
 	case "none":
-		return ""
+		return "", ""
 	}
-	return *flag
+	return *flag, ""
 }
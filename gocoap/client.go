@@ -6,198 +6,299 @@ import (
 	"fmt"
 	"github.com/plgd-dev/go-coap/v3/message"
 	"io"
-	"net/url"
-	"strings"
 	"time"
 
+	"github.com/larryr/tools/gocoap/senml"
+	piondtls "github.com/pion/dtls/v3"
+	coapdtls "github.com/plgd-dev/go-coap/v3/dtls"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/message/pool"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
+	"github.com/plgd-dev/go-coap/v3/options"
+	"github.com/plgd-dev/go-coap/v3/pkg/runner/periodic"
 	coapudp "github.com/plgd-dev/go-coap/v3/udp"
 	coapclient "github.com/plgd-dev/go-coap/v3/udp/client"
 )
 
 // Client represents a CoAP client.
 type Client struct {
-	timeout time.Duration
+	timeout       time.Duration
+	blockSize     blockwise.SZX
+	ackTimeout    time.Duration
+	maxRetransmit uint32
+	pskIdentity   []byte
+	psk           []byte
+	options       message.Options
 }
 
-// NewClient creates a new CoAP client with the specified timeout.
-func NewClient(timeout time.Duration) *Client {
-	if timeout == 0 {
-		timeout = 5 * time.Second
-	}
-	return &Client{
-		timeout: timeout,
+// Option is a function that configures the Client.
+type Option func(c *Client)
+
+// WithBlockSize sets the block size used for block-wise transfers.
+// Use ValidateBlockSize to convert a size in bytes.
+//
+// Default is 1024 bytes.
+func WithBlockSize(szx blockwise.SZX) Option {
+	return func(c *Client) {
+		c.blockSize = szx
 	}
 }
 
-// Get performs a GET request to the specified URL.
-// TODO add options
-func (c *Client) Get(url string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
-	defer cancel()
+// WithTransmission sets the RFC 7252 transmission parameters for confirmable messages:
+// the initial ACK_TIMEOUT and MAX_RETRANSMIT.
+//
+// Default is 2 seconds and 4 retransmissions.
+func WithTransmission(ackTimeout time.Duration, maxRetransmit uint32) Option {
+	return func(c *Client) {
+		c.ackTimeout = ackTimeout
+		c.maxRetransmit = maxRetransmit
+	}
+}
 
-	// Parse the URL to get the host and path
-	host, path, err := parseURL(url)
-	if err != nil {
-		return nil, err
+// WithPSK sets the DTLS pre-shared key and identity used for coaps:// URLs.
+func WithPSK(identity string, key []byte) Option {
+	return func(c *Client) {
+		c.pskIdentity = []byte(identity)
+		c.psk = key
 	}
+}
 
-	// Create a CoAP client connection
-	conn, err := coapudp.Dial(host)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial: %v", err)
+// WithDefaultOptions adds CoAP options that are sent with every request, e.g. Accept.
+func WithDefaultOptions(opts ...message.Option) Option {
+	return func(c *Client) {
+		c.options = append(c.options, opts...)
 	}
-	defer connClose(conn)
+}
 
-	resp, err := conn.Get(ctx, path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+// NewClient creates a new CoAP client with the specified timeout.
+func NewClient(timeout time.Duration, opts ...Option) *Client {
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+	c := &Client{
+		timeout:       timeout,
+		blockSize:     blockwise.SZX1024,
+		ackTimeout:    2 * time.Second,
+		maxRetransmit: 4,
+	}
+	for _, opt := range opts {
+		opt(c)
 	}
+	return c
+}
 
-	// Read the response body
+// Response is a CoAP response with its code, options and fully read body.
+type Response struct {
+	Code          codes.Code
+	ContentFormat message.MediaType
+	// HasContentFormat is false when the response carried no Content-Format option.
+	HasContentFormat bool
+	// Observe is the sequence number of a notification; HasObserve is false for plain responses.
+	Observe    uint32
+	HasObserve bool
+	Options    message.Options
+	Body       []byte
+	// Peer is the address of the server that sent the response.
+	Peer string
+	// Received is the time the response arrived and RTT the time since the request was sent.
+	// RTT is zero for notifications after the first.
+	Received time.Time
+	RTT      time.Duration
+}
 
-	body, err := io.ReadAll(resp.Body())
+// IsSuccess returns true if the response code is in the 2.xx class.
+func (r *Response) IsSuccess() bool {
+	return r.Code>>5 == 2
+}
+
+// IsSenML returns true if the response payload is SenML JSON or CBOR.
+func (r *Response) IsSenML() bool {
+	return r.HasContentFormat && senml.IsSenML(r.ContentFormat)
+}
+
+// SenML decodes the response payload and returns the resolved SenML records.
+func (r *Response) SenML() (senml.Pack, error) {
+	if !r.IsSenML() {
+		return nil, fmt.Errorf("response is not SenML (content format %v)", r.ContentFormat)
+	}
+	p, err := senml.Decode(r.Body, r.ContentFormat)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
-	return body, nil
+	return p.Resolve(time.Now())
 }
 
-// Post performs a POST request to the specified URL with the given payload.
-func (c *Client) Post(url string, contentId message.MediaType, payload io.ReadSeeker) ([]byte, error) {
+// Request performs a request with the given method code to the specified URL and returns the full response.
+// contentId and payload are only used by POST and PUT.
+func (c *Client) Request(method codes.Code, url string, contentId message.MediaType, payload io.ReadSeeker) (*Response, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
 	defer cancel()
 
-	// Parse the URL to get the host and path
-	host, path, err := parseURL(url)
+	uri, err := ParseURI(url)
 	if err != nil {
 		return nil, err
 	}
 
 	// Create a CoAP client connection
-	conn, err := coapudp.Dial(host)
+	conn, err := c.dial(uri)
 	if err != nil {
-		return nil, fmt.Errorf("failed to dial: %v", err)
+		return nil, err
 	}
 	defer connClose(conn)
 
-	// Read payload if provided
-	var payloadBytes []byte
-	if payload != nil {
-		payloadBytes, err = io.ReadAll(payload)
-		if err != nil {
-			return nil, fmt.Errorf("failed to read payload: %v", err)
-		}
+	// The path is passed as decoded Uri-Path options rather than as a string,
+	// so segments containing an encoded '/' survive
+	opts := c.requestOptions(uri)
+	start := time.Now()
+	var resp *pool.Message
+	switch method {
+	case codes.GET:
+		resp, err = conn.Get(ctx, "", opts...)
+	case codes.POST:
+		resp, err = conn.Post(ctx, "", contentId, payload, opts...)
+	case codes.PUT:
+		resp, err = conn.Put(ctx, "", contentId, payload, opts...)
+	case codes.DELETE:
+		resp, err = conn.Delete(ctx, "", opts...)
+	default:
+		return nil, fmt.Errorf("unsupported method: %v", method)
 	}
-
-	resp, err := conn.Post(ctx, path, contentId, strings.NewReader(string(payloadBytes)))
 	if err != nil {
 		return nil, fmt.Errorf("failed to send request: %v", err)
 	}
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body())
+	r, err := readResponse(resp)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
-	return body, nil
+	r.Peer = conn.RemoteAddr().String()
+	r.RTT = r.Received.Sub(start)
+	return r, nil
 }
 
-// Put performs a PUT request to the specified URL with the given payload.
-func (c *Client) Put(url string, contentId message.MediaType, payload io.ReadSeeker) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
-	defer cancel()
-
-	// Parse the URL to get the host and path
-	host, path, err := parseURL(url)
+// Get performs a GET request to the specified URL.
+// TODO add options
+func (c *Client) Get(url string) ([]byte, error) {
+	resp, err := c.Request(codes.GET, url, 0, nil)
 	if err != nil {
 		return nil, err
 	}
+	return resp.Body, nil
+}
 
-	// Create a CoAP client connection
-	conn, err := coapudp.Dial(host)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial: %v", err)
-	}
-	defer connClose(conn)
-
-	resp, err := conn.Put(ctx, path, contentId, payload)
+// Post performs a POST request to the specified URL with the given payload.
+// Payloads larger than the block size are sent block-wise without being read into memory.
+func (c *Client) Post(url string, contentId message.MediaType, payload io.ReadSeeker) ([]byte, error) {
+	resp, err := c.Request(codes.POST, url, contentId, payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+		return nil, err
 	}
+	return resp.Body, nil
+}
 
-	// Read the response body
-	body, err := io.ReadAll(resp.Body())
+// Put performs a PUT request to the specified URL with the given payload.
+func (c *Client) Put(url string, contentId message.MediaType, payload io.ReadSeeker) ([]byte, error) {
+	resp, err := c.Request(codes.PUT, url, contentId, payload)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+		return nil, err
 	}
-	return body, nil
+	return resp.Body, nil
 }
 
 // Delete performs a DELETE request to the specified URL.
 func (c *Client) Delete(url string) ([]byte, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
-	defer cancel()
-
-	// Parse the URL to get the host and path
-	host, path, err := parseURL(url)
+	resp, err := c.Request(codes.DELETE, url, 0, nil)
 	if err != nil {
 		return nil, err
 	}
+	return resp.Body, nil
+}
 
-	// Create a CoAP client connection
-	conn, err := coapudp.Dial(host)
-	if err != nil {
-		return nil, fmt.Errorf("failed to dial: %v", err)
+// readResponse reads the body and the options of a received message.
+// The options are copied because the message goes back to its pool.
+func readResponse(msg *pool.Message) (*Response, error) {
+	resp := &Response{
+		Code:     msg.Code(),
+		Received: time.Now(),
 	}
-	defer connClose(conn)
-
-	resp, err := conn.Delete(ctx, path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %v", err)
+	if cf, err := msg.ContentFormat(); err == nil {
+		resp.ContentFormat = cf
+		resp.HasContentFormat = true
+	}
+	if obs, err := msg.Observe(); err == nil {
+		resp.Observe = obs
+		resp.HasObserve = true
+	}
+	for _, o := range msg.Options() {
+		resp.Options = append(resp.Options, message.Option{ID: o.ID, Value: append([]byte(nil), o.Value...)})
 	}
 
 	// Read the response body
-	body, err := io.ReadAll(resp.Body())
-	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %v", err)
+	if msg.Body() != nil {
+		body, err := io.ReadAll(msg.Body())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read response body: %v", err)
+		}
+		resp.Body = body
 	}
-	return body, nil
+	return resp, nil
 }
 
-// parseURL parses a CoAP URL and returns the host and path.
-func parseURL(rawURL string) (string, string, error) {
-	// Check if the URL starts with coap:// or coaps://
-	if !strings.HasPrefix(rawURL, "coap://") && !strings.HasPrefix(rawURL, "coaps://") {
-		return "", "", fmt.Errorf("invalid CoAP URL (must start with coap:// or coaps://): %s", rawURL)
-	}
+// requestOptions returns the Uri-Path and Uri-Query options for uri followed by the default options.
+func (c *Client) requestOptions(uri *URI) message.Options {
+	return append(uri.Options(), c.options...)
+}
 
-	// Replace the scheme with http:// or https:// to use the net/url package
-	var httpURL string
-	if strings.HasPrefix(rawURL, "coap://") {
-		httpURL = "http://" + rawURL[7:]
+// dial creates a CoAP connection to the host of uri with the client's block-wise and transmission settings.
+// coaps:// URLs are dialed over DTLS with the client's pre-shared key.
+func (c *Client) dial(uri *URI, opts ...coapudp.Option) (*coapclient.Conn, error) {
+	opts = append([]coapudp.Option{
+		options.WithBlockwise(true, c.blockSize, c.timeout),
+		options.WithTransmission(1, c.ackTimeout, c.maxRetransmit),
+		options.WithPeriodicRunner(expirationRunner(c.ackTimeout / 2)),
+	}, opts...)
+
+	var conn *coapclient.Conn
+	var err error
+	if uri.Scheme == "coaps" {
+		if c.psk == nil {
+			return nil, fmt.Errorf("failed to dial: coaps requires a pre-shared key")
+		}
+		conn, err = coapdtls.Dial(uri.Address(), c.dtlsConfig(), opts...)
 	} else {
-		httpURL = "https://" + rawURL[8:]
+		conn, err = coapudp.Dial(uri.Address(), opts...)
 	}
-
-	// Parse the URL using the net/url package
-	parsedURL, err := url.Parse(httpURL)
 	if err != nil {
-		return "", "", fmt.Errorf("failed to parse URL: %v", err)
+		return nil, fmt.Errorf("failed to dial: %v", err)
 	}
+	return conn, nil
+}
 
-	// Extract the host and path
-	host := parsedURL.Host
-	path := parsedURL.Path
-	if path == "" {
-		path = "/"
+// dtlsConfig returns the DTLS configuration for the client's pre-shared key.
+func (c *Client) dtlsConfig() *piondtls.Config {
+	return &piondtls.Config{
+		PSK: func(hint []byte) ([]byte, error) {
+			return c.psk, nil
+		},
+		PSKIdentityHint: c.pskIdentity,
+		CipherSuites: []piondtls.CipherSuiteID{
+			piondtls.TLS_PSK_WITH_AES_128_CCM_8,
+			piondtls.TLS_PSK_WITH_AES_128_GCM_SHA256,
+		},
 	}
+}
 
-	// Add query parameters to the path if present
-	if parsedURL.RawQuery != "" {
-		path += "?" + parsedURL.RawQuery
+// expirationRunner returns a periodic runner that checks for retransmissions and expired
+// requests every tick, until the connection is closed. The go-coap default only checks
+// every few seconds, which is too coarse for short ACK timeouts.
+func expirationRunner(tick time.Duration) periodic.Func {
+	return func(f func(now time.Time) bool) {
+		go func() {
+			for f(time.Now()) {
+				time.Sleep(tick)
+			}
+		}()
 	}
-
-	return host, path, nil
 }
 
 func connClose(conn *coapclient.Conn) {
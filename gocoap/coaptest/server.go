@@ -0,0 +1,408 @@
+// Package coaptest provides an in-process CoAP server for hermetic unit tests of
+// code built on gocoap, in the spirit of net/http/httptest.
+//
+// The server listens on a loopback UDP port, answers requests with programmable
+// responses and records every datagram it receives. Response delays and packet loss
+// can be injected to exercise the client's retransmission logic.
+package coaptest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math/rand"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/message/pool"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
+	"github.com/plgd-dev/go-coap/v3/udp/coder"
+)
+
+// maxDatagramSize is large enough for any CoAP message over UDP.
+const maxDatagramSize = 65535
+
+// defaultBlockSize is used for Block2 responses when the client did not ask for a size.
+const defaultBlockSize = blockwise.SZX1024
+
+// Request is a datagram received by the Server.
+type Request struct {
+	Type      message.Type
+	Code      codes.Code
+	MessageID int32
+	Token     message.Token
+	Path      string
+	Queries   []string
+	Options   message.Options
+	Payload   []byte
+	From      net.Addr
+	Received  time.Time
+	// Dropped is true when the datagram was discarded by the loss injection.
+	Dropped bool
+	// Duplicate is true for a retransmission that was answered from the response cache.
+	Duplicate bool
+}
+
+// ContentFormat returns the Content-Format option of the request.
+func (r *Request) ContentFormat() (message.MediaType, error) {
+	return r.Options.ContentFormat()
+}
+
+// Response is a programmed answer to a request.
+type Response struct {
+	Code          codes.Code
+	ContentFormat *message.MediaType
+	Payload       []byte
+}
+
+// HandlerFunc computes the response to a request. The request payload of a block-wise
+// upload is the reassembled payload, unless SetUploadBlocks was called. Returning nil sends
+// no response at all.
+// Handlers run on the server goroutine, so state they share with a test must be synchronized.
+type HandlerFunc func(r *Request) *Response
+
+// Server is a CoAP server listening on a loopback port.
+type Server struct {
+	// URL is the base URL of the server, e.g. coap://127.0.0.1:54321
+	URL string
+	// Addr is the address the server listens on.
+	Addr string
+
+	conn *net.UDPConn
+	wg   sync.WaitGroup
+
+	mu       sync.Mutex
+	handlers map[string]HandlerFunc
+	requests []Request
+	delay    time.Duration
+	loss     float64
+	dropNext int
+	blocks   bool
+	rand     *rand.Rand
+	sent     map[string][]byte
+	uploads  map[string]*bytes.Buffer
+}
+
+// NewServer starts and returns a new Server. The caller should call Close when finished.
+// Paths without a handler are answered with 4.04 Not Found.
+func NewServer() *Server {
+	conn, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		panic(fmt.Sprintf("coaptest: failed to listen on a port: %v", err))
+	}
+	s := &Server{
+		Addr:     conn.LocalAddr().String(),
+		URL:      "coap://" + conn.LocalAddr().String(),
+		conn:     conn,
+		handlers: make(map[string]HandlerFunc),
+		rand:     rand.New(rand.NewSource(1)),
+		sent:     make(map[string][]byte),
+		uploads:  make(map[string]*bytes.Buffer),
+	}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close shuts down the server and waits for it to stop.
+func (s *Server) Close() {
+	_ = s.conn.Close()
+	s.wg.Wait()
+}
+
+// Handle programs a fixed response for requests to path.
+func (s *Server) Handle(path string, resp Response) {
+	s.HandleFunc(path, func(*Request) *Response {
+		r := resp
+		return &r
+	})
+}
+
+// HandleFunc registers a handler for requests to path.
+func (s *Server) HandleFunc(path string, handler HandlerFunc) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.handlers[normalizePath(path)] = handler
+}
+
+// SetDelay delays every response by d.
+func (s *Server) SetDelay(d time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.delay = d
+}
+
+// SetLoss drops each received datagram with the given probability (0 to 1).
+// The random source is seeded so test runs are repeatable.
+func (s *Server) SetLoss(probability float64, seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.loss = probability
+	s.rand = rand.New(rand.NewSource(seed))
+}
+
+// DropNext drops the next n received datagrams.
+func (s *Server) DropNext(n int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.dropNext = n
+}
+
+// SetUploadBlocks hands each block of a block-wise upload to the handler as it arrives,
+// with its Block1 option, instead of the reassembled payload. The handler then answers
+// every block itself, e.g. with 2.31 Continue or with an early final response.
+func (s *Server) SetUploadBlocks(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocks = enabled
+}
+
+// Requests returns a copy of every datagram received so far, in order of arrival.
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Request(nil), s.requests...)
+}
+
+// ClearRequests forgets the recorded requests.
+func (s *Server) ClearRequests() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.requests = nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	buf := make([]byte, maxDatagramSize)
+	for {
+		n, from, err := s.conn.ReadFromUDP(buf)
+		if err != nil {
+			return
+		}
+		data := append([]byte(nil), buf[:n]...)
+		s.handleDatagram(data, from)
+	}
+}
+
+func (s *Server) handleDatagram(data []byte, from *net.UDPAddr) {
+	msg := pool.NewMessage(context.Background())
+	if _, err := msg.UnmarshalWithDecoder(coder.DefaultCoder, data); err != nil {
+		return
+	}
+	payload, err := msg.ReadBody()
+	if err != nil {
+		return
+	}
+	req := Request{
+		Type:      msg.Type(),
+		Code:      msg.Code(),
+		MessageID: msg.MessageID(),
+		Token:     msg.Token(),
+		Options:   msg.Options(),
+		Payload:   payload,
+		From:      from,
+		Received:  time.Now(),
+	}
+	req.Path, _ = msg.Path()
+	req.Queries, _ = msg.Queries()
+
+	s.mu.Lock()
+	req.Dropped = s.shouldDrop()
+	cacheKey := fmt.Sprintf("%s/%d", from, req.MessageID)
+	cached, duplicate := s.sent[cacheKey]
+	req.Duplicate = !req.Dropped && duplicate && req.Type == message.Confirmable
+	s.requests = append(s.requests, req)
+	delay := s.delay
+	s.mu.Unlock()
+
+	switch {
+	case req.Dropped:
+		return
+	case req.Duplicate:
+		s.send(cached, from, delay)
+		return
+	}
+
+	var resp *pool.Message
+	if req.Code == codes.Empty {
+		// a CoAP ping is answered with a reset
+		if req.Type != message.Confirmable {
+			return
+		}
+		resp = pool.NewMessage(context.Background())
+		resp.SetType(message.Reset)
+		resp.SetCode(codes.Empty)
+		resp.SetMessageID(req.MessageID)
+	} else {
+		resp = s.respond(&req)
+		if resp == nil {
+			return
+		}
+	}
+	out, err := resp.MarshalWithEncoder(coder.DefaultCoder)
+	if err != nil {
+		return
+	}
+	if req.Type == message.Confirmable {
+		s.mu.Lock()
+		s.sent[cacheKey] = out
+		s.mu.Unlock()
+	}
+	s.send(out, from, delay)
+}
+
+// shouldDrop decides whether the loss injection discards the current datagram. s.mu must be held.
+func (s *Server) shouldDrop() bool {
+	if s.dropNext > 0 {
+		s.dropNext--
+		return true
+	}
+	return s.loss > 0 && s.rand.Float64() < s.loss
+}
+
+func (s *Server) send(data []byte, to *net.UDPAddr, delay time.Duration) {
+	if delay <= 0 {
+		_, _ = s.conn.WriteToUDP(data, to)
+		return
+	}
+	s.wg.Add(1)
+	time.AfterFunc(delay, func() {
+		defer s.wg.Done()
+		_, _ = s.conn.WriteToUDP(data, to)
+	})
+}
+
+// respond runs the handler for a request and takes care of block-wise transfers in both directions.
+func (s *Server) respond(req *Request) *pool.Message {
+	resp := pool.NewMessage(context.Background())
+	resp.SetToken(req.Token)
+	if req.Type == message.Confirmable {
+		resp.SetType(message.Acknowledgement)
+		resp.SetMessageID(req.MessageID)
+	} else {
+		resp.SetType(message.NonConfirmable)
+		resp.SetMessageID(int32(s.nextMessageID()))
+	}
+
+	// a block-wise upload is acknowledged block by block and handled once complete
+	block1, err := req.Options.GetUint32(message.Block1)
+	isUpload := err == nil
+	s.mu.Lock()
+	perBlock := s.blocks
+	s.mu.Unlock()
+	if isUpload && !perBlock {
+		complete, err := s.collectUpload(req, block1)
+		if err != nil {
+			resp.SetCode(codes.RequestEntityIncomplete)
+			return resp
+		}
+		if complete == nil {
+			resp.SetCode(codes.Continue)
+			resp.SetOptionUint32(message.Block1, block1)
+			return resp
+		}
+		r := *req
+		r.Payload = complete
+		req = &r
+	}
+
+	s.mu.Lock()
+	handler, ok := s.handlers[normalizePath(req.Path)]
+	s.mu.Unlock()
+	if !ok {
+		resp.SetCode(codes.NotFound)
+		return resp
+	}
+	result := handler(req)
+	if result == nil {
+		return nil
+	}
+	resp.SetCode(result.Code)
+	if result.ContentFormat != nil {
+		resp.SetContentFormat(*result.ContentFormat)
+	}
+	if isUpload {
+		resp.SetOptionUint32(message.Block1, block1)
+	}
+	payload := result.Payload
+
+	// large payloads, or a client asking for a block, get a Block2 response
+	szx := defaultBlockSize
+	num := int64(0)
+	blockRequested := false
+	if block, err := req.Options.GetUint32(message.Block2); err == nil {
+		if reqSzx, reqNum, _, err := blockwise.DecodeBlockOption(block); err == nil {
+			blockRequested = true
+			num = reqNum
+			if reqSzx < szx {
+				szx = reqSzx
+			}
+		}
+	}
+	if blockRequested || int64(len(payload)) > szx.Size() {
+		start := num * szx.Size()
+		if start > int64(len(payload)) {
+			resp.SetCode(codes.BadOption)
+			return resp
+		}
+		end := start + szx.Size()
+		more := end < int64(len(payload))
+		if !more {
+			end = int64(len(payload))
+		}
+		block, _ := blockwise.EncodeBlockOption(szx, num, more)
+		resp.SetOptionUint32(message.Block2, block)
+		if num == 0 {
+			resp.SetOptionUint32(message.Size2, uint32(len(payload)))
+		}
+		payload = payload[start:end]
+	}
+	if len(payload) > 0 {
+		resp.SetBody(bytes.NewReader(payload))
+	}
+	return resp
+}
+
+// collectUpload appends a Block1 fragment to the upload in progress. It returns the whole
+// payload once the last block arrived and nil while more blocks are expected.
+func (s *Server) collectUpload(req *Request, block uint32) ([]byte, error) {
+	szx, num, more, err := blockwise.DecodeBlockOption(block)
+	if err != nil {
+		return nil, err
+	}
+	key := fmt.Sprintf("%s %s", req.From, req.Path)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	upload, ok := s.uploads[key]
+	if num == 0 || !ok {
+		upload = &bytes.Buffer{}
+		s.uploads[key] = upload
+	}
+	if int64(upload.Len()) != num*szx.Size() {
+		delete(s.uploads, key)
+		return nil, fmt.Errorf("block %d out of order", num)
+	}
+	upload.Write(req.Payload)
+	if more {
+		return nil, nil
+	}
+	delete(s.uploads, key)
+	return upload.Bytes(), nil
+}
+
+// nextMessageID returns a message id for non-confirmable responses.
+func (s *Server) nextMessageID() uint16 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return uint16(s.rand.Uint32())
+}
+
+func normalizePath(path string) string {
+	for len(path) > 0 && path[0] == '/' {
+		path = path[1:]
+	}
+	return "/" + path
+}
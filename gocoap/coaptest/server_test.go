@@ -0,0 +1,126 @@
+package coaptest_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larryr/tools/gocoap"
+	"github.com/larryr/tools/gocoap/coaptest"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
+)
+
+func TestHandleAndRecord(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	cf := message.TextPlain
+	srv.Handle("/temp", coaptest.Response{Code: codes.Content, ContentFormat: &cf, Payload: []byte("21.5")})
+
+	client := gocoap.NewClient(time.Second)
+	body, err := client.Get(srv.URL + "/temp?unit=C")
+	if err != nil {
+		t.Fatalf("TestHandleAndRecord: expected no error, got %s", err)
+	}
+	if string(body) != "21.5" {
+		t.Errorf("TestHandleAndRecord: expected body 21.5, got %s", body)
+	}
+
+	requests := srv.Requests()
+	if len(requests) != 1 {
+		t.Fatalf("TestHandleAndRecord: expected 1 request, got %d", len(requests))
+	}
+	r := requests[0]
+	if r.Code != codes.GET || r.Path != "/temp" || r.Type != message.Confirmable || len(r.Queries) != 1 || r.Queries[0] != "unit=C" {
+		t.Errorf("TestHandleAndRecord: unexpected request %+v", r)
+	}
+}
+
+func TestNotFound(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+
+	resp, err := gocoap.NewClient(time.Second).Request(codes.GET, srv.URL+"/missing", 0, nil)
+	if err != nil {
+		t.Fatalf("TestNotFound: expected no error, got %s", err)
+	}
+	if resp.Code != codes.NotFound {
+		t.Errorf("TestNotFound: expected %v, got %v", codes.NotFound, resp.Code)
+	}
+}
+
+func TestPacketLossRetransmission(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	srv.Handle("/x", coaptest.Response{Code: codes.Content, Payload: []byte("ok")})
+	srv.DropNext(2)
+
+	client := gocoap.NewClient(2*time.Second, gocoap.WithTransmission(50*time.Millisecond, 4))
+	body, err := client.Get(srv.URL + "/x")
+	if err != nil {
+		t.Fatalf("TestPacketLossRetransmission: expected no error, got %s", err)
+	}
+	if string(body) != "ok" {
+		t.Errorf("TestPacketLossRetransmission: expected body ok, got %s", body)
+	}
+	requests := srv.Requests()
+	if len(requests) != 3 {
+		t.Fatalf("TestPacketLossRetransmission: expected 3 datagrams, got %d", len(requests))
+	}
+	if !requests[0].Dropped || !requests[1].Dropped || requests[2].Dropped {
+		t.Errorf("TestPacketLossRetransmission: expected the first two datagrams to be dropped")
+	}
+	if requests[0].MessageID != requests[2].MessageID {
+		t.Errorf("TestPacketLossRetransmission: expected retransmissions to reuse message id %d, got %d", requests[0].MessageID, requests[2].MessageID)
+	}
+}
+
+func TestDelayTimesOut(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	srv.Handle("/slow", coaptest.Response{Code: codes.Content})
+	srv.SetDelay(500 * time.Millisecond)
+
+	_, err := gocoap.NewClient(100 * time.Millisecond).Get(srv.URL + "/slow")
+	if err == nil {
+		t.Errorf("TestDelayTimesOut: expected a timeout error")
+	}
+}
+
+func TestBlockwise(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	image := []byte(strings.Repeat("0123456789abcdef", 300))
+	uploaded := make(chan []byte, 1)
+	srv.HandleFunc("/fw", func(r *coaptest.Request) *coaptest.Response {
+		if r.Code == codes.PUT {
+			uploaded <- r.Payload
+			return &coaptest.Response{Code: codes.Changed}
+		}
+		return &coaptest.Response{Code: codes.Content, Payload: image}
+	})
+	client := gocoap.NewClient(time.Second, gocoap.WithBlockSize(blockwise.SZX512))
+
+	buf := &bytes.Buffer{}
+	if _, err := client.Download(srv.URL+"/fw", buf, nil); err != nil {
+		t.Fatalf("TestBlockwise: expected no error, got %s", err)
+	}
+	if !bytes.Equal(buf.Bytes(), image) {
+		t.Errorf("TestBlockwise: downloaded payload does not match")
+	}
+
+	resp, err := client.Upload(codes.PUT, srv.URL+"/fw", message.AppOctets, bytes.NewReader(image), nil)
+	if err != nil {
+		t.Fatalf("TestBlockwise: expected no error, got %s", err)
+	}
+	var payload []byte
+	select {
+	case payload = <-uploaded:
+	default:
+	}
+	if resp.Code != codes.Changed || !bytes.Equal(payload, image) {
+		t.Errorf("TestBlockwise: expected the upload to be reassembled, got %v with %d bytes", resp.Code, len(payload))
+	}
+}
@@ -0,0 +1,219 @@
+// Package gocoap provides functionality for interacting with CoAP servers.
+package gocoap
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"gopkg.in/yaml.v3"
+)
+
+// configNames are the file names looked up in the gocoap config directory, in order.
+var configNames = []string{"config.yaml", "config.yml", "config.toml"}
+
+// Config is the contents of a gocoap config file.
+//
+// Example config.yaml:
+//
+//	profiles:
+//	  kitchen-sensor:
+//	    url: coaps://[fe80::1%eth0]
+//	    identity: kitchen
+//	    psk: secret
+//	    ack_timeout: 500ms
+//	    headers:
+//	      accept: 110
+type Config struct {
+	Profiles map[string]*Profile `yaml:"profiles" toml:"profiles"`
+}
+
+// Profile holds the settings for talking to one endpoint.
+// Fields that are not set in the config file are left at their zero value.
+type Profile struct {
+	// URL is the base URL that the path of a @profile/path target is appended to.
+	URL string `yaml:"url" toml:"url"`
+	// Identity is the DTLS PSK identity.
+	Identity string `yaml:"identity" toml:"identity"`
+	// PSK is the DTLS pre-shared key as text; PSKHex is the same key hex encoded.
+	PSK    string `yaml:"psk" toml:"psk"`
+	PSKHex string `yaml:"psk_hex" toml:"psk_hex"`
+	// Timeout is the overall request timeout.
+	Timeout time.Duration `yaml:"timeout" toml:"timeout"`
+	// AckTimeout and MaxRetransmit are the RFC 7252 transmission parameters.
+	AckTimeout    time.Duration `yaml:"ack_timeout" toml:"ack_timeout"`
+	MaxRetransmit *uint32       `yaml:"max_retransmit" toml:"max_retransmit"`
+	// BlockSize is the block size in bytes for block-wise transfers.
+	BlockSize int `yaml:"block_size" toml:"block_size"`
+	// ContentFormat is the content format of request payloads.
+	ContentFormat *int `yaml:"content_format" toml:"content_format"`
+	// Headers are CoAP options sent with every request, keyed by option name
+	// (e.g. accept, uri-host, if-none-match) or number.
+	Headers map[string]string `yaml:"headers" toml:"headers"`
+}
+
+// DefaultConfigPath returns the path of the first config file found in
+// $XDG_CONFIG_HOME/gocoap or ~/.config/gocoap, or "" if there is none.
+func DefaultConfigPath() (string, error) {
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find the config directory: %v", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+	for _, name := range configNames {
+		path := filepath.Join(dir, "gocoap", name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", nil
+}
+
+// LoadConfig reads a YAML config file, or a TOML one if the name ends in .toml.
+// Unknown keys are reported as errors so that typos do not go unnoticed.
+func LoadConfig(path string) (*Config, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config: %v", err)
+	}
+	cfg := &Config{}
+	if strings.HasSuffix(path, ".toml") {
+		md, err := toml.Decode(string(data), cfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+		}
+		if undecoded := md.Undecoded(); len(undecoded) > 0 {
+			return nil, fmt.Errorf("failed to parse config %s: unknown key %s", path, undecoded[0])
+		}
+	} else {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config %s: %v", path, err)
+		}
+	}
+	return cfg, nil
+}
+
+// Expand resolves a @profile/path target to a URL and returns the profile it names.
+// Targets that do not start with '@' are returned unchanged with a nil profile.
+func (c *Config) Expand(target string) (string, *Profile, error) {
+	if !strings.HasPrefix(target, "@") {
+		return target, nil, nil
+	}
+	name, rest := target[1:], ""
+	if i := strings.IndexAny(name, "/?"); i >= 0 {
+		name, rest = name[:i], name[i:]
+	}
+	p, ok := c.Profiles[name]
+	if !ok {
+		return "", nil, fmt.Errorf("unknown profile: %s", name)
+	}
+	if p.URL == "" {
+		return "", nil, fmt.Errorf("profile %s has no url", name)
+	}
+	return strings.TrimSuffix(p.URL, "/") + rest, p, nil
+}
+
+// ClientOptions returns the client options for the settings of the profile.
+// The timeout and content format are not client options and are left to the caller.
+func (p *Profile) ClientOptions() ([]Option, error) {
+	var opts []Option
+	if p.PSK != "" || p.PSKHex != "" {
+		key := []byte(p.PSK)
+		if p.PSKHex != "" {
+			var err error
+			if key, err = hex.DecodeString(p.PSKHex); err != nil {
+				return nil, fmt.Errorf("invalid psk_hex: %v", err)
+			}
+		}
+		opts = append(opts, WithPSK(p.Identity, key))
+	}
+	if p.AckTimeout > 0 || p.MaxRetransmit != nil {
+		opts = append(opts, func(c *Client) {
+			if p.AckTimeout > 0 {
+				c.ackTimeout = p.AckTimeout
+			}
+			if p.MaxRetransmit != nil {
+				c.maxRetransmit = *p.MaxRetransmit
+			}
+		})
+	}
+	if p.BlockSize != 0 {
+		szx, err := ValidateBlockSize(p.BlockSize)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithBlockSize(szx))
+	}
+	if len(p.Headers) > 0 {
+		headers, err := ParseHeaders(p.Headers)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, WithDefaultOptions(headers...))
+	}
+	return opts, nil
+}
+
+// ParseHeaders converts a map of option names or numbers to values into CoAP options.
+// Names are matched ignoring case, '-' and '_', so If-None-Match and if_none_match both work.
+// Values of uint options are decimal numbers, opaque values may be hex with a 0x prefix,
+// and anything else is sent as text.
+func ParseHeaders(headers map[string]string) (message.Options, error) {
+	var opts message.Options
+	for name, value := range headers {
+		id, err := optionID(name)
+		if err != nil {
+			return nil, err
+		}
+		var v []byte
+		switch message.CoapOptionDefs[id].ValueFormat {
+		case message.ValueUint:
+			n, err := strconv.ParseUint(value, 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for header %s: %s", name, value)
+			}
+			buf := make([]byte, 4)
+			l, _ := message.EncodeUint32(buf, uint32(n))
+			v = buf[:l]
+		case message.ValueOpaque:
+			v = []byte(value)
+			if strings.HasPrefix(value, "0x") {
+				if v, err = hex.DecodeString(value[2:]); err != nil {
+					return nil, fmt.Errorf("invalid value for header %s: %s", name, value)
+				}
+			}
+		default:
+			v = []byte(value)
+		}
+		opts = opts.Add(message.Option{ID: id, Value: v})
+	}
+	return opts, nil
+}
+
+// optionID looks up an option by name or number.
+func optionID(name string) (message.OptionID, error) {
+	if n, err := strconv.ParseUint(name, 10, 16); err == nil {
+		return message.OptionID(n), nil
+	}
+	normalize := func(s string) string {
+		return strings.ToLower(strings.NewReplacer("-", "", "_", "").Replace(s))
+	}
+	for id := range message.CoapOptionDefs {
+		if normalize(id.String()) == normalize(name) {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown header: %s", name)
+}
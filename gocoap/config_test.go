@@ -0,0 +1,178 @@
+package gocoap
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	piondtls "github.com/pion/dtls/v3"
+	coapdtls "github.com/plgd-dev/go-coap/v3/dtls"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/mux"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/options"
+)
+
+const yamlConfig = `
+profiles:
+  kitchen-sensor:
+    url: coaps://[fe80::1%eth0]/
+    identity: kitchen
+    psk: secret
+    timeout: 10s
+    ack_timeout: 500ms
+    max_retransmit: 2
+    block_size: 256
+    content_format: 50
+    headers:
+      accept: 110
+      If-None-Match: ""
+`
+
+const tomlConfig = `
+[profiles.gateway]
+url = "coap://gateway.local:5700/api"
+psk_hex = "abc123"
+timeout = "3s"
+
+[profiles.gateway.headers]
+uri-host = "gw"
+`
+
+func writeConfig(t *testing.T, name, data string) string {
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, "config.yaml", yamlConfig))
+	if err != nil {
+		t.Fatalf("TestLoadConfigYAML: expected no error, got %s", err)
+	}
+	url, p, err := cfg.Expand("@kitchen-sensor/temp?unit=C")
+	if err != nil {
+		t.Fatalf("TestLoadConfigYAML: expected no error, got %s", err)
+	}
+	if url != "coaps://[fe80::1%eth0]/temp?unit=C" {
+		t.Errorf("TestLoadConfigYAML: unexpected url %s", url)
+	}
+	if p.Timeout != 10*time.Second || p.AckTimeout != 500*time.Millisecond || *p.MaxRetransmit != 2 || *p.ContentFormat != 50 {
+		t.Errorf("TestLoadConfigYAML: unexpected profile %+v", p)
+	}
+
+	opts, err := p.ClientOptions()
+	if err != nil {
+		t.Fatalf("TestLoadConfigYAML: expected no error, got %s", err)
+	}
+	c := NewClient(time.Second, opts...)
+	if string(c.psk) != "secret" || string(c.pskIdentity) != "kitchen" {
+		t.Errorf("TestLoadConfigYAML: expected the psk to be set, got %q %q", c.pskIdentity, c.psk)
+	}
+	if c.ackTimeout != 500*time.Millisecond || c.maxRetransmit != 2 || c.blockSize.Size() != 256 {
+		t.Errorf("TestLoadConfigYAML: unexpected client settings %+v", c)
+	}
+	expected := message.Options{
+		{ID: message.IfNoneMatch, Value: []byte{}},
+		{ID: message.Accept, Value: []byte{110}},
+	}
+	if len(c.options) != 2 || c.options[0].ID != expected[0].ID || !bytes.Equal(c.options[1].Value, expected[1].Value) {
+		t.Errorf("TestLoadConfigYAML: expected options %v, got %v", expected, c.options)
+	}
+}
+
+func TestLoadConfigTOML(t *testing.T) {
+	cfg, err := LoadConfig(writeConfig(t, "config.toml", tomlConfig))
+	if err != nil {
+		t.Fatalf("TestLoadConfigTOML: expected no error, got %s", err)
+	}
+	url, p, err := cfg.Expand("@gateway/nodes/1")
+	if err != nil {
+		t.Fatalf("TestLoadConfigTOML: expected no error, got %s", err)
+	}
+	if url != "coap://gateway.local:5700/api/nodes/1" || p.Timeout != 3*time.Second {
+		t.Errorf("TestLoadConfigTOML: unexpected url %s or timeout %s", url, p.Timeout)
+	}
+	opts, err := p.ClientOptions()
+	if err != nil {
+		t.Fatalf("TestLoadConfigTOML: expected no error, got %s", err)
+	}
+	c := NewClient(time.Second, opts...)
+	if !bytes.Equal(c.psk, []byte{0xab, 0xc1, 0x23}) {
+		t.Errorf("TestLoadConfigTOML: expected the hex psk to be decoded, got %x", c.psk)
+	}
+	if len(c.options) != 1 || c.options[0].ID != message.URIHost || string(c.options[0].Value) != "gw" {
+		t.Errorf("TestLoadConfigTOML: expected a Uri-Host option, got %v", c.options)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	if _, err := LoadConfig(writeConfig(t, "config.yaml", "profiles:\n  x:\n    ulr: coap://h\n")); err == nil {
+		t.Errorf("TestConfigErrors: expected an error for an unknown yaml key")
+	}
+	if _, err := LoadConfig(writeConfig(t, "config.toml", "[profiles.x]\nulr = \"coap://h\"\n")); err == nil {
+		t.Errorf("TestConfigErrors: expected an error for an unknown toml key")
+	}
+
+	cfg := &Config{Profiles: map[string]*Profile{"nourl": {}}}
+	if _, _, err := cfg.Expand("@missing/x"); err == nil {
+		t.Errorf("TestConfigErrors: expected an error for an unknown profile")
+	}
+	if _, _, err := cfg.Expand("@nourl/x"); err == nil {
+		t.Errorf("TestConfigErrors: expected an error for a profile without url")
+	}
+	if url, p, err := cfg.Expand("coap://h/x"); err != nil || p != nil || url != "coap://h/x" {
+		t.Errorf("TestConfigErrors: expected a plain url to be unchanged, got %s %v %v", url, p, err)
+	}
+
+	if _, err := ParseHeaders(map[string]string{"no-such-option": "1"}); err == nil {
+		t.Errorf("TestConfigErrors: expected an error for an unknown header")
+	}
+	if _, err := ParseHeaders(map[string]string{"accept": "json"}); err == nil {
+		t.Errorf("TestConfigErrors: expected an error for a non-numeric accept value")
+	}
+}
+
+func TestPSK(t *testing.T) {
+	key := []byte{0xab, 0xc1, 0x23}
+	router := mux.NewRouter()
+	err := router.Handle("/secure", mux.HandlerFunc(func(w mux.ResponseWriter, r *mux.Message) {
+		_ = w.SetResponse(codes.Content, message.TextPlain, bytes.NewReader([]byte("hello")))
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := coapnet.NewDTLSListener("udp", "127.0.0.1:0", &piondtls.Config{
+		PSK: func(hint []byte) ([]byte, error) {
+			return key, nil
+		},
+		PSKIdentityHint: []byte("server"),
+		CipherSuites:    []piondtls.CipherSuiteID{piondtls.TLS_PSK_WITH_AES_128_CCM_8},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := coapdtls.NewServer(options.WithMux(router))
+	go func() { _ = s.Serve(l) }()
+	defer func() {
+		s.Stop()
+		_ = l.Close()
+	}()
+
+	url := "coaps://" + l.Addr().String() + "/secure"
+	body, err := NewClient(2*time.Second, WithPSK("client", key)).Get(url)
+	if err != nil {
+		t.Fatalf("TestPSK: expected no error, got %s", err)
+	}
+	if string(body) != "hello" {
+		t.Errorf("TestPSK: expected body hello, got %s", body)
+	}
+	if _, err := NewClient(time.Second).Get(url); err == nil {
+		t.Errorf("TestPSK: expected an error without a pre-shared key")
+	}
+}
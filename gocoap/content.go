@@ -4,6 +4,7 @@ package gocoap
 import (
 	"fmt"
 	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
 )
 
 // ValidateContentType validates the given coap content coding id integer
@@ -29,3 +30,15 @@ func ValidateContentType(contentId int) (message.MediaType, error) {
 	}
 	return 0, fmt.Errorf("invalid media type: %d", contentId)
 }
+
+// ValidateBlockSize validates the given block size in bytes and returns the
+// matching blockwise.SZX value.
+// It returns an error unless the size is a power of two between 16 and 1024.
+func ValidateBlockSize(size int) (blockwise.SZX, error) {
+	for szx := blockwise.SZX16; szx <= blockwise.SZX1024; szx++ {
+		if szx.Size() == int64(size) {
+			return szx, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid block size: %d (must be 16, 32, 64, 128, 256, 512 or 1024)", size)
+}
@@ -0,0 +1,132 @@
+// Package gocoap provides functionality for interacting with CoAP servers.
+package gocoap
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"time"
+	"unicode/utf8"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/larryr/tools/gocoap/senml"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+)
+
+// jsonResponse is the JSON form of a Response.
+type jsonResponse struct {
+	Code          string       `json:"code"`
+	Status        string       `json:"status"`
+	Peer          string       `json:"peer,omitempty"`
+	Time          time.Time    `json:"time"`
+	RTT           float64      `json:"rtt_ms,omitempty"`
+	Observe       *uint32      `json:"observe,omitempty"`
+	ContentFormat *uint16      `json:"content_format,omitempty"`
+	ContentType   string       `json:"content_type,omitempty"`
+	Options       []jsonOption `json:"options"`
+	Payload       any          `json:"payload,omitempty"`
+	// PayloadBase64 holds payloads that cannot be decoded; encoding/json writes []byte as base64.
+	PayloadBase64 []byte `json:"payload_base64,omitempty"`
+}
+
+// jsonOption is the JSON form of a CoAP option. Value is a number for uint options,
+// a string for string options and hex for opaque ones.
+type jsonOption struct {
+	ID    message.OptionID `json:"id"`
+	Name  string           `json:"name"`
+	Value any              `json:"value"`
+}
+
+// cborDecMode decodes CBOR maps with string keys so they can be written as JSON objects.
+var cborDecMode, _ = cbor.DecOptions{
+	DefaultMapType: reflect.TypeOf(map[string]any(nil)),
+}.DecMode()
+
+// MarshalJSON writes the response as one JSON object with its code, options, content format,
+// timing, peer address and the payload decoded according to its content format:
+// JSON is embedded as is, SenML as resolved records, CBOR converted to JSON and text as a string.
+// Other payloads are written base64 encoded in payload_base64.
+func (r *Response) MarshalJSON() ([]byte, error) {
+	jr := jsonResponse{
+		Code:    codeString(r.Code),
+		Status:  r.Code.String(),
+		Peer:    r.Peer,
+		Time:    r.Received,
+		RTT:     float64(r.RTT) / float64(time.Millisecond),
+		Options: make([]jsonOption, 0, len(r.Options)),
+	}
+	if r.HasObserve {
+		obs := r.Observe
+		jr.Observe = &obs
+	}
+	if r.HasContentFormat {
+		cf := uint16(r.ContentFormat)
+		jr.ContentFormat = &cf
+		jr.ContentType = r.ContentFormat.String()
+	}
+	for _, o := range r.Options {
+		jr.Options = append(jr.Options, jsonOption{ID: o.ID, Name: o.ID.String(), Value: optionValue(o)})
+	}
+	jr.Payload, jr.PayloadBase64 = r.decodePayload()
+	return json.Marshal(jr)
+}
+
+// decodePayload returns the payload as a value for encoding/json or, if that is not possible, as raw bytes.
+func (r *Response) decodePayload() (any, []byte) {
+	if len(r.Body) == 0 {
+		return nil, nil
+	}
+	if r.IsSenML() {
+		if p, err := r.SenML(); err == nil {
+			if data, err := senml.EncodeJSON(p); err == nil {
+				return json.RawMessage(data), nil
+			}
+		}
+		return nil, r.Body
+	}
+	if !r.HasContentFormat {
+		if utf8.Valid(r.Body) {
+			return string(r.Body), nil
+		}
+		return nil, r.Body
+	}
+	switch r.ContentFormat {
+	case message.AppJSON:
+		if json.Valid(r.Body) {
+			return json.RawMessage(r.Body), nil
+		}
+	case message.AppCBOR:
+		var v any
+		if err := cborDecMode.Unmarshal(r.Body, &v); err == nil {
+			if _, err := json.Marshal(v); err == nil {
+				return v, nil
+			}
+		}
+	case message.TextPlain, message.AppLinkFormat, message.AppXML:
+		if utf8.Valid(r.Body) {
+			return string(r.Body), nil
+		}
+	}
+	return nil, r.Body
+}
+
+// codeString formats a code in the dotted c.dd notation of RFC 7252, e.g. 2.05.
+func codeString(c codes.Code) string {
+	return fmt.Sprintf("%d.%02d", c>>5, c&0x1f)
+}
+
+// optionValue returns the value of an option in the form used by jsonOption.
+func optionValue(o message.Option) any {
+	switch message.CoapOptionDefs[o.ID].ValueFormat {
+	case message.ValueUint:
+		v, _, err := message.DecodeUint32(o.Value)
+		if err == nil {
+			return v
+		}
+	case message.ValueString:
+		return string(o.Value)
+	}
+	return hex.EncodeToString(o.Value)
+}
@@ -0,0 +1,63 @@
+package gocoap
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+)
+
+func TestResponseMarshalJSON(t *testing.T) {
+	received := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name     string
+		cf       int
+		body     []byte
+		expected map[string]any
+	}{
+		{"text", int(message.TextPlain), []byte("21.5"), map[string]any{"payload": "21.5"}},
+		{"json", int(message.AppJSON), []byte(`{"t":21.5}`), map[string]any{"payload": map[string]any{"t": 21.5}}},
+		{"cbor", int(message.AppCBOR), []byte{0xa1, 0x61, 0x74, 0x18, 0x15}, map[string]any{"payload": map[string]any{"t": 21.0}}},
+		{"senml", 110, []byte(`[{"bn":"dev/","n":"t","v":21.5,"t":1.7e9}]`), map[string]any{"payload": []any{map[string]any{"n": "dev/t", "v": 21.5, "t": 1.7e9}}}},
+		{"binary", int(message.AppOctets), []byte{0, 1, 2}, map[string]any{"payload_base64": "AAEC"}},
+		{"none", -1, nil, map[string]any{}},
+	}
+	for _, tt := range tests {
+		resp := &Response{
+			Code:     codes.Content,
+			Body:     tt.body,
+			Peer:     "127.0.0.1:5683",
+			Received: received,
+			RTT:      1500 * time.Microsecond,
+			Options:  message.Options{{ID: message.ETag, Value: []byte{0xbe, 0xef}}},
+		}
+		if tt.cf >= 0 {
+			resp.ContentFormat = message.MediaType(tt.cf)
+			resp.HasContentFormat = true
+		}
+		data, err := json.Marshal(resp)
+		if err != nil {
+			t.Fatalf("TestResponseMarshalJSON: %s: expected no error, got %s", tt.name, err)
+		}
+		var got map[string]any
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("TestResponseMarshalJSON: %s: invalid JSON %s", tt.name, data)
+		}
+		if got["code"] != "2.05" || got["status"] != "Content" || got["peer"] != "127.0.0.1:5683" ||
+			got["rtt_ms"] != 1.5 || got["time"] != "2024-05-01T12:00:00Z" {
+			t.Errorf("TestResponseMarshalJSON: %s: unexpected header fields in %s", tt.name, data)
+		}
+		expectedOptions := []any{map[string]any{"id": 4.0, "name": "ETag", "value": "beef"}}
+		if !reflect.DeepEqual(got["options"], expectedOptions) {
+			t.Errorf("TestResponseMarshalJSON: %s: expected options %v, got %v", tt.name, expectedOptions, got["options"])
+		}
+		for _, key := range []string{"payload", "payload_base64"} {
+			if !reflect.DeepEqual(got[key], tt.expected[key]) {
+				t.Errorf("TestResponseMarshalJSON: %s: expected %s %v, got %v", tt.name, key, tt.expected[key], got[key])
+			}
+		}
+	}
+}
@@ -0,0 +1,153 @@
+// Package lwm2m decodes and encodes the payload formats of OMA LwM2M (TLV, SenML JSON
+// and CBOR, plain text and opaque) and names objects and resources from a registry of
+// the core object definitions.
+//
+// Payloads are represented as a flat list of Resources, each holding the full path of a
+// resource or resource instance and its value as a Go value: string, int64, float64,
+// bool, time.Time, []byte or ObjLnk.
+package lwm2m
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/larryr/tools/gocoap/senml"
+	"github.com/plgd-dev/go-coap/v3/message"
+)
+
+// LwM2M content formats.
+const (
+	ContentFormatText   message.MediaType = 0     // text/plain
+	ContentFormatOpaque message.MediaType = 42    // application/octet-stream
+	ContentFormatTLV    message.MediaType = 11542 // application/vnd.oma.lwm2m+tlv
+)
+
+// Path is an LwM2M path: object, instance, resource and resource instance ids,
+// of which only a prefix may be present. The empty path is the root.
+type Path []uint16
+
+// ParsePath parses a path like /3/0/7.
+func ParsePath(s string) (Path, error) {
+	s = strings.Trim(s, "/")
+	if s == "" {
+		return Path{}, nil
+	}
+	parts := strings.Split(s, "/")
+	if len(parts) > 4 {
+		return nil, fmt.Errorf("invalid LwM2M path %q: too many levels", s)
+	}
+	p := make(Path, len(parts))
+	for i, part := range parts {
+		id, err := strconv.ParseUint(part, 10, 16)
+		if err != nil {
+			return nil, fmt.Errorf("invalid LwM2M path %q: %v", s, err)
+		}
+		p[i] = uint16(id)
+	}
+	return p, nil
+}
+
+// String formats the path, e.g. /3/0/7.
+func (p Path) String() string {
+	if len(p) == 0 {
+		return "/"
+	}
+	sb := &strings.Builder{}
+	for _, id := range p {
+		sb.WriteString("/" + strconv.Itoa(int(id)))
+	}
+	return sb.String()
+}
+
+// Append returns a new path with ids appended.
+func (p Path) Append(ids ...uint16) Path {
+	return append(append(Path(nil), p...), ids...)
+}
+
+// Less orders paths by their ids, parents before children.
+func (p Path) Less(q Path) bool {
+	for i := 0; i < len(p) && i < len(q); i++ {
+		if p[i] != q[i] {
+			return p[i] < q[i]
+		}
+	}
+	return len(p) < len(q)
+}
+
+// ObjLnk is a link to an object instance.
+type ObjLnk struct {
+	Object   uint16
+	Instance uint16
+}
+
+// String formats the link as object:instance.
+func (l ObjLnk) String() string {
+	return fmt.Sprintf("%d:%d", l.Object, l.Instance)
+}
+
+// Resource is the value of a single resource or resource instance.
+type Resource struct {
+	Path  Path
+	Value any
+}
+
+// Decode parses a payload of the given content format that was read from base.
+// The registry gives the types of TLV and plain text values; values of unknown
+// resources are returned as []byte. reg may be nil.
+func Decode(data []byte, cf message.MediaType, base Path, reg *Registry) ([]Resource, error) {
+	var resources []Resource
+	var err error
+	switch cf {
+	case ContentFormatTLV:
+		resources, err = decodeTLVResources(data, base, reg)
+	case senml.ContentFormatJSON, senml.ContentFormatCBOR:
+		resources, err = decodeSenML(data, cf, reg)
+	case ContentFormatText:
+		if len(base) < 3 {
+			return nil, fmt.Errorf("plain text payloads are only valid for a single resource, not %s", base)
+		}
+		var v any
+		v, err = parseText(string(data), reg.Resource(base[0], base[2]).typeOrDefault(TypeString))
+		resources = []Resource{{Path: base, Value: v}}
+	case ContentFormatOpaque:
+		if len(base) < 3 {
+			return nil, fmt.Errorf("opaque payloads are only valid for a single resource, not %s", base)
+		}
+		resources = []Resource{{Path: base, Value: append([]byte(nil), data...)}}
+	default:
+		return nil, fmt.Errorf("unsupported LwM2M content format: %v", cf)
+	}
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(resources, func(i, j int) bool { return resources[i].Path.Less(resources[j].Path) })
+	return resources, nil
+}
+
+// Encode writes resources below base in the given content format.
+// Plain text and opaque payloads hold exactly one resource.
+func Encode(resources []Resource, cf message.MediaType, base Path) ([]byte, error) {
+	switch cf {
+	case ContentFormatTLV:
+		return encodeTLVResources(resources, base)
+	case senml.ContentFormatJSON, senml.ContentFormatCBOR:
+		return encodeSenML(resources, cf)
+	case ContentFormatText:
+		if len(resources) != 1 {
+			return nil, fmt.Errorf("plain text payloads hold one resource, got %d", len(resources))
+		}
+		return []byte(formatText(resources[0].Value)), nil
+	case ContentFormatOpaque:
+		if len(resources) != 1 {
+			return nil, fmt.Errorf("opaque payloads hold one resource, got %d", len(resources))
+		}
+		b, ok := resources[0].Value.([]byte)
+		if !ok {
+			return nil, fmt.Errorf("opaque payloads hold []byte, got %T", resources[0].Value)
+		}
+		return b, nil
+	}
+	return nil, fmt.Errorf("unsupported LwM2M content format: %v", cf)
+}
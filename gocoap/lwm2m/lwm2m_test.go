@@ -0,0 +1,188 @@
+package lwm2m
+
+import (
+	"bytes"
+	"encoding/hex"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/larryr/tools/gocoap/senml"
+)
+
+// deviceTLV is the example read of /3/0 from the OMA LwM2M 1.0 specification.
+const deviceTLV = "c800144f70656e204d6f62696c6520416c6c69616e6365" +
+	"c801164c69676874776569676874204d324d20436c69656e74" +
+	"c80209333435303030313233" +
+	"c303312e30" +
+	"8606410001410105" +
+	"88070842000ed842011388" +
+	"8808084200007d42010384" +
+	"c10964" +
+	"c10a0f" +
+	"830b410000" +
+	"c40d5182428f" +
+	"c60e2b30323a3030" +
+	"c11055"
+
+func TestDecodeTLV(t *testing.T) {
+	data, _ := hex.DecodeString(deviceTLV)
+	resources, err := Decode(data, ContentFormatTLV, Path{3, 0}, DefaultRegistry())
+	if err != nil {
+		t.Fatalf("TestDecodeTLV: expected no error, got %s", err)
+	}
+	expected := map[string]any{
+		"/3/0/0":    "Open Mobile Alliance",
+		"/3/0/1":    "Lightweight M2M Client",
+		"/3/0/2":    "345000123",
+		"/3/0/3":    "1.0",
+		"/3/0/6/0":  int64(1),
+		"/3/0/6/1":  int64(5),
+		"/3/0/7/0":  int64(3800),
+		"/3/0/7/1":  int64(5000),
+		"/3/0/8/0":  int64(125),
+		"/3/0/8/1":  int64(900),
+		"/3/0/9":    int64(100),
+		"/3/0/10":   int64(15),
+		"/3/0/11/0": int64(0),
+		"/3/0/13":   time.Unix(1367491215, 0).UTC(),
+		"/3/0/14":   "+02:00",
+		"/3/0/16":   "U",
+	}
+	if len(resources) != len(expected) {
+		t.Fatalf("TestDecodeTLV: expected %d resources, got %d", len(expected), len(resources))
+	}
+	for _, r := range resources {
+		if !reflect.DeepEqual(r.Value, expected[r.Path.String()]) {
+			t.Errorf("TestDecodeTLV: expected %s to be %v, got %v (%T)", r.Path, expected[r.Path.String()], r.Value, r.Value)
+		}
+	}
+
+	// the encoder uses the shortest integers, so compare the decoded round trip
+	encoded, err := Encode(resources, ContentFormatTLV, Path{3, 0})
+	if err != nil {
+		t.Fatalf("TestDecodeTLV: expected no error, got %s", err)
+	}
+	again, err := Decode(encoded, ContentFormatTLV, Path{3, 0}, DefaultRegistry())
+	if err != nil {
+		t.Fatalf("TestDecodeTLV: expected no error, got %s", err)
+	}
+	if !reflect.DeepEqual(again, resources) {
+		t.Errorf("TestDecodeTLV: round trip changed %v to %v", resources, again)
+	}
+}
+
+func TestTLVBases(t *testing.T) {
+	reg := DefaultRegistry()
+	tests := []struct {
+		base      Path
+		resources []Resource
+		hex       string
+	}{
+		{Path{3, 0, 9}, []Resource{{Path{3, 0, 9}, int64(100)}}, "c10964"},
+		{Path{3, 0, 7}, []Resource{{Path{3, 0, 7, 0}, int64(3800)}, {Path{3, 0, 7, 1}, int64(5000)}}, "88070842000ed842011388"},
+		{Path{1}, []Resource{{Path{1, 0, 0}, int64(1)}, {Path{1, 0, 1}, int64(86400)}, {Path{1, 1, 0}, int64(2)}}, "080009c10001c401000151800301c10002"},
+	}
+	for _, tt := range tests {
+		data, err := Encode(tt.resources, ContentFormatTLV, tt.base)
+		if err != nil {
+			t.Errorf("TestTLVBases: %s: expected no error, got %s", tt.base, err)
+			continue
+		}
+		if hex.EncodeToString(data) != tt.hex {
+			t.Errorf("TestTLVBases: %s: expected %s, got %x", tt.base, tt.hex, data)
+		}
+		resources, err := Decode(data, ContentFormatTLV, tt.base, reg)
+		if err != nil || !reflect.DeepEqual(resources, tt.resources) {
+			t.Errorf("TestTLVBases: %s: expected %v, got %v %v", tt.base, tt.resources, resources, err)
+		}
+	}
+
+	if _, err := Decode([]byte{0xc8, 0x00, 0x14, 'a'}, ContentFormatTLV, Path{3, 0}, reg); err == nil {
+		t.Errorf("TestTLVBases: expected an error for a truncated value")
+	}
+}
+
+func TestSenMLAndText(t *testing.T) {
+	reg := DefaultRegistry()
+	data := []byte(`[{"bn":"/3/0/","n":"0","vs":"Acme"},{"n":"9","v":95},{"n":"13","v":1367491215},{"n":"7/0","v":3800},{"n":"22/0","vs":"4:0"}]`)
+	resources, err := Decode(data, senml.ContentFormatJSON, Path{3, 0}, reg)
+	if err != nil {
+		t.Fatalf("TestSenMLAndText: expected no error, got %s", err)
+	}
+	expected := []Resource{
+		{Path{3, 0, 0}, "Acme"},
+		{Path{3, 0, 7, 0}, int64(3800)},
+		{Path{3, 0, 9}, int64(95)},
+		{Path{3, 0, 13}, time.Unix(1367491215, 0).UTC()},
+		{Path{3, 0, 22, 0}, ObjLnk{Object: 4, Instance: 0}},
+	}
+	if !reflect.DeepEqual(resources, expected) {
+		t.Errorf("TestSenMLAndText: expected %v, got %v", expected, resources)
+	}
+
+	// SenML CBOR round trip
+	cbor, err := Encode(resources, senml.ContentFormatCBOR, Path{3, 0})
+	if err != nil {
+		t.Fatalf("TestSenMLAndText: expected no error, got %s", err)
+	}
+	again, err := Decode(cbor, senml.ContentFormatCBOR, Path{3, 0}, reg)
+	if err != nil || !reflect.DeepEqual(again, expected) {
+		t.Errorf("TestSenMLAndText: expected %v, got %v %v", expected, again, err)
+	}
+
+	v, err := Decode([]byte("95"), ContentFormatText, Path{3, 0, 9}, reg)
+	if err != nil || !reflect.DeepEqual(v, []Resource{{Path{3, 0, 9}, int64(95)}}) {
+		t.Errorf("TestSenMLAndText: expected 95, got %v %v", v, err)
+	}
+	text, err := Encode([]Resource{{Path{1, 0, 6}, true}}, ContentFormatText, Path{1, 0, 6})
+	if err != nil || string(text) != "1" {
+		t.Errorf("TestSenMLAndText: expected 1, got %s %v", text, err)
+	}
+	if _, err := Decode([]byte("95"), ContentFormatText, Path{3, 0}, reg); err == nil {
+		t.Errorf("TestSenMLAndText: expected an error for plain text of an instance")
+	}
+}
+
+func TestWriteTree(t *testing.T) {
+	resources := []Resource{
+		{Path{3, 0, 7, 1}, int64(5000)},
+		{Path{3, 0, 0}, "Acme"},
+		{Path{3, 0, 7, 0}, int64(3800)},
+		{Path{1, 0, 1}, int64(86400)},
+		{Path{3, 0, 99}, []byte{1, 2}},
+	}
+	buf := &bytes.Buffer{}
+	if err := WriteTree(buf, resources, DefaultRegistry()); err != nil {
+		t.Fatalf("TestWriteTree: expected no error, got %s", err)
+	}
+	expected := strings.Join([]string{
+		"/1 LwM2M Server",
+		"  /1/0",
+		"    /1/0/1 Lifetime: 86400",
+		"/3 Device",
+		"  /3/0",
+		`    /3/0/0 Manufacturer: "Acme"`,
+		"    /3/0/7 Power Source Voltage",
+		"      /3/0/7/0: 3800",
+		"      /3/0/7/1: 5000",
+		"    /3/0/99: 0x0102",
+		"",
+	}, "\n")
+	if buf.String() != expected {
+		t.Errorf("TestWriteTree: expected\n%s\ngot\n%s", expected, buf.String())
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	p, err := ParsePath("/3/0/7/1")
+	if err != nil || !reflect.DeepEqual(p, Path{3, 0, 7, 1}) || p.String() != "/3/0/7/1" {
+		t.Errorf("TestParsePath: expected /3/0/7/1, got %v %v", p, err)
+	}
+	for _, s := range []string{"/3/x", "/1/2/3/4/5", "/70000"} {
+		if _, err := ParsePath(s); err == nil {
+			t.Errorf("TestParsePath: expected an error for %s", s)
+		}
+	}
+}
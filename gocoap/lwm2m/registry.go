@@ -0,0 +1,177 @@
+package lwm2m
+
+// ObjectDef describes an LwM2M object.
+type ObjectDef struct {
+	ID        uint16
+	Name      string
+	Multiple  bool
+	Resources map[uint16]*ResourceDef
+}
+
+// ResourceDef describes a resource of an object.
+// Executable resources have TypeNone.
+type ResourceDef struct {
+	ID       uint16
+	Name     string
+	Type     Type
+	Multiple bool
+}
+
+// typeOrDefault returns the type of the resource, or def for an unknown resource.
+func (r *ResourceDef) typeOrDefault(def Type) Type {
+	if r == nil || r.Type == TypeNone {
+		return def
+	}
+	return r.Type
+}
+
+// Registry holds object definitions by object id.
+type Registry struct {
+	objects map[uint16]*ObjectDef
+}
+
+// NewRegistry returns a registry with the given object definitions.
+func NewRegistry(objects ...*ObjectDef) *Registry {
+	r := &Registry{objects: make(map[uint16]*ObjectDef)}
+	for _, o := range objects {
+		r.Add(o)
+	}
+	return r
+}
+
+// Add adds or replaces an object definition.
+func (r *Registry) Add(o *ObjectDef) {
+	r.objects[o.ID] = o
+}
+
+// Object returns the definition of an object, or nil if it is unknown. r may be nil.
+func (r *Registry) Object(id uint16) *ObjectDef {
+	if r == nil {
+		return nil
+	}
+	return r.objects[id]
+}
+
+// Resource returns the definition of a resource, or nil if it is unknown. r may be nil.
+func (r *Registry) Resource(object, resource uint16) *ResourceDef {
+	o := r.Object(object)
+	if o == nil {
+		return nil
+	}
+	return o.Resources[resource]
+}
+
+// object builds an ObjectDef from its resources.
+func object(id uint16, name string, multiple bool, resources ...*ResourceDef) *ObjectDef {
+	o := &ObjectDef{ID: id, Name: name, Multiple: multiple, Resources: make(map[uint16]*ResourceDef)}
+	for _, r := range resources {
+		o.Resources[r.ID] = r
+	}
+	return o
+}
+
+func single(id uint16, name string, t Type) *ResourceDef {
+	return &ResourceDef{ID: id, Name: name, Type: t}
+}
+
+func multiple(id uint16, name string, t Type) *ResourceDef {
+	return &ResourceDef{ID: id, Name: name, Type: t, Multiple: true}
+}
+
+// DefaultRegistry returns a registry of the core objects defined by OMA LwM2M 1.0:
+// Security, Server, Access Control, Device, Connectivity Monitoring, Firmware Update
+// and Location.
+func DefaultRegistry() *Registry {
+	return NewRegistry(
+		object(0, "LWM2M Security", true,
+			single(0, "LWM2M Server URI", TypeString),
+			single(1, "Bootstrap-Server", TypeBoolean),
+			single(2, "Security Mode", TypeInteger),
+			single(3, "Public Key or Identity", TypeOpaque),
+			single(4, "Server Public Key", TypeOpaque),
+			single(5, "Secret Key", TypeOpaque),
+			single(6, "SMS Security Mode", TypeInteger),
+			single(7, "SMS Binding Key Parameters", TypeOpaque),
+			single(8, "SMS Binding Secret Key(s)", TypeOpaque),
+			single(9, "LwM2M Server SMS Number", TypeString),
+			single(10, "Short Server ID", TypeInteger),
+			single(11, "Client Hold Off Time", TypeInteger),
+			single(12, "Bootstrap-Server Account Timeout", TypeInteger),
+		),
+		object(1, "LwM2M Server", true,
+			single(0, "Short Server ID", TypeInteger),
+			single(1, "Lifetime", TypeInteger),
+			single(2, "Default Minimum Period", TypeInteger),
+			single(3, "Default Maximum Period", TypeInteger),
+			single(4, "Disable", TypeNone),
+			single(5, "Disable Timeout", TypeInteger),
+			single(6, "Notification Storing When Disabled or Offline", TypeBoolean),
+			single(7, "Binding", TypeString),
+			single(8, "Registration Update Trigger", TypeNone),
+		),
+		object(2, "LwM2M Access Control", true,
+			single(0, "Object ID", TypeInteger),
+			single(1, "Object Instance ID", TypeInteger),
+			multiple(2, "ACL", TypeInteger),
+			single(3, "Access Control Owner", TypeInteger),
+		),
+		object(3, "Device", false,
+			single(0, "Manufacturer", TypeString),
+			single(1, "Model Number", TypeString),
+			single(2, "Serial Number", TypeString),
+			single(3, "Firmware Version", TypeString),
+			single(4, "Reboot", TypeNone),
+			single(5, "Factory Reset", TypeNone),
+			multiple(6, "Available Power Sources", TypeInteger),
+			multiple(7, "Power Source Voltage", TypeInteger),
+			multiple(8, "Power Source Current", TypeInteger),
+			single(9, "Battery Level", TypeInteger),
+			single(10, "Memory Free", TypeInteger),
+			multiple(11, "Error Code", TypeInteger),
+			single(12, "Reset Error Code", TypeNone),
+			single(13, "Current Time", TypeTime),
+			single(14, "UTC Offset", TypeString),
+			single(15, "Timezone", TypeString),
+			single(16, "Supported Binding and Modes", TypeString),
+			single(17, "Device Type", TypeString),
+			single(18, "Hardware Version", TypeString),
+			single(19, "Software Version", TypeString),
+			single(20, "Battery Status", TypeInteger),
+			single(21, "Memory Total", TypeInteger),
+			multiple(22, "ExtDevInfo", TypeObjLnk),
+		),
+		object(4, "Connectivity Monitoring", false,
+			single(0, "Network Bearer", TypeInteger),
+			multiple(1, "Available Network Bearer", TypeInteger),
+			single(2, "Radio Signal Strength", TypeInteger),
+			single(3, "Link Quality", TypeInteger),
+			multiple(4, "IP Addresses", TypeString),
+			multiple(5, "Router IP Addresses", TypeString),
+			single(6, "Link Utilization", TypeInteger),
+			multiple(7, "APN", TypeString),
+			single(8, "Cell ID", TypeInteger),
+			single(9, "SMNC", TypeInteger),
+			single(10, "SMCC", TypeInteger),
+		),
+		object(5, "Firmware Update", false,
+			single(0, "Package", TypeOpaque),
+			single(1, "Package URI", TypeString),
+			single(2, "Update", TypeNone),
+			single(3, "State", TypeInteger),
+			single(5, "Update Result", TypeInteger),
+			single(6, "PkgName", TypeString),
+			single(7, "PkgVersion", TypeString),
+			multiple(8, "Firmware Update Protocol Support", TypeInteger),
+			single(9, "Firmware Update Delivery Method", TypeInteger),
+		),
+		object(6, "Location", false,
+			single(0, "Latitude", TypeFloat),
+			single(1, "Longitude", TypeFloat),
+			single(2, "Altitude", TypeFloat),
+			single(3, "Radius", TypeFloat),
+			single(4, "Velocity", TypeOpaque),
+			single(5, "Timestamp", TypeTime),
+			single(6, "Speed", TypeFloat),
+		),
+	)
+}
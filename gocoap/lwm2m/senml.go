@@ -0,0 +1,107 @@
+package lwm2m
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/larryr/tools/gocoap/senml"
+	"github.com/plgd-dev/go-coap/v3/message"
+)
+
+// decodeSenML converts SenML records named by LwM2M paths into resources.
+// LwM2M names start with '/', which RFC 8428 does not allow, so names are
+// resolved here instead of by senml.Pack.Resolve.
+func decodeSenML(data []byte, cf message.MediaType, reg *Registry) ([]Resource, error) {
+	pack, err := senml.Decode(data, cf)
+	if err != nil {
+		return nil, err
+	}
+	var resources []Resource
+	baseName := ""
+	for _, r := range pack {
+		if r.BaseName != "" {
+			baseName = r.BaseName
+		}
+		name := baseName + r.Name
+		p, err := ParsePath(name)
+		if err != nil {
+			return nil, err
+		}
+		if len(p) < 3 {
+			return nil, fmt.Errorf("SenML record %q does not name a resource", name)
+		}
+		t := reg.Resource(p[0], p[2]).typeOrDefault(TypeNone)
+		var v any
+		switch {
+		case r.Value != nil:
+			v = numberValue(*r.Value, t)
+		case r.StringValue != nil && t == TypeObjLnk:
+			if v, err = parseObjLnk(*r.StringValue); err != nil {
+				return nil, err
+			}
+		case r.StringValue != nil:
+			v = *r.StringValue
+		case r.BoolValue != nil:
+			v = *r.BoolValue
+		case r.DataValue != nil:
+			v = r.DataValue
+		default:
+			return nil, fmt.Errorf("SenML record %q has no value", name)
+		}
+		resources = append(resources, Resource{Path: p, Value: v})
+	}
+	return resources, nil
+}
+
+// numberValue converts a SenML number to the Go value for t.
+func numberValue(f float64, t Type) any {
+	switch t {
+	case TypeInteger:
+		return int64(f)
+	case TypeUnsignedInteger:
+		return uint64(f)
+	case TypeTime:
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+	}
+	return f
+}
+
+// encodeSenML writes one SenML record per resource, named by its full path.
+// Object links are written as strings in vs.
+func encodeSenML(resources []Resource, cf message.MediaType) ([]byte, error) {
+	pack := make(senml.Pack, 0, len(resources))
+	for _, res := range resources {
+		r := senml.Record{Name: res.Path.String()}
+		switch v := res.Value.(type) {
+		case int:
+			f := float64(v)
+			r.Value = &f
+		case int64:
+			f := float64(v)
+			r.Value = &f
+		case uint64:
+			f := float64(v)
+			r.Value = &f
+		case float64:
+			r.Value = &v
+		case time.Time:
+			f := float64(v.Unix())
+			r.Value = &f
+		case string:
+			r.StringValue = &v
+		case ObjLnk:
+			s := v.String()
+			r.StringValue = &s
+		case bool:
+			r.BoolValue = &v
+		case []byte:
+			r.DataValue = v
+		default:
+			return nil, fmt.Errorf("unsupported value type %T for %s", v, res.Path)
+		}
+		pack = append(pack, r)
+	}
+	return senml.Encode(pack, cf)
+}
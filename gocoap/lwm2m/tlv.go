@@ -0,0 +1,263 @@
+package lwm2m
+
+import (
+	"fmt"
+)
+
+// TLVKind is the identifier type in bits 7-6 of a TLV type byte.
+type TLVKind byte
+
+const (
+	TLVObjectInstance   TLVKind = 0
+	TLVResourceInstance TLVKind = 1
+	TLVMultipleResource TLVKind = 2
+	TLVResource         TLVKind = 3
+)
+
+// TLV is one entry of an LwM2M TLV payload (OMA LwM2M 1.0 section 6.4.3).
+// Object instances and multiple resources hold their entries in Children,
+// resources and resource instances hold their value in Value.
+type TLV struct {
+	Kind     TLVKind
+	ID       uint16
+	Value    []byte
+	Children []TLV
+}
+
+// DecodeTLV parses a TLV payload.
+func DecodeTLV(data []byte) ([]TLV, error) {
+	var tlvs []TLV
+	for len(data) > 0 {
+		t, n, err := decodeOneTLV(data)
+		if err != nil {
+			return nil, err
+		}
+		tlvs = append(tlvs, t)
+		data = data[n:]
+	}
+	return tlvs, nil
+}
+
+func decodeOneTLV(data []byte) (TLV, int, error) {
+	typ := data[0]
+	t := TLV{Kind: TLVKind(typ >> 6)}
+	pos := 1
+	idLen := 1
+	if typ&0x20 != 0 {
+		idLen = 2
+	}
+	lenLen := int(typ>>3) & 0x03
+	if len(data) < pos+idLen+lenLen {
+		return TLV{}, 0, fmt.Errorf("truncated TLV header")
+	}
+	for i := 0; i < idLen; i++ {
+		t.ID = t.ID<<8 | uint16(data[pos+i])
+	}
+	pos += idLen
+	length := int(typ & 0x07)
+	if lenLen > 0 {
+		length = 0
+		for i := 0; i < lenLen; i++ {
+			length = length<<8 | int(data[pos+i])
+		}
+		pos += lenLen
+	}
+	if len(data) < pos+length {
+		return TLV{}, 0, fmt.Errorf("truncated TLV value of %d bytes for id %d", length, t.ID)
+	}
+	value := data[pos : pos+length]
+	if t.Kind == TLVObjectInstance || t.Kind == TLVMultipleResource {
+		children, err := DecodeTLV(value)
+		if err != nil {
+			return TLV{}, 0, err
+		}
+		t.Children = children
+	} else {
+		t.Value = append([]byte(nil), value...)
+	}
+	return t, pos + length, nil
+}
+
+// EncodeTLV writes TLV entries.
+func EncodeTLV(tlvs []TLV) ([]byte, error) {
+	var out []byte
+	for _, t := range tlvs {
+		value := t.Value
+		if t.Kind == TLVObjectInstance || t.Kind == TLVMultipleResource {
+			var err error
+			if value, err = EncodeTLV(t.Children); err != nil {
+				return nil, err
+			}
+		}
+		if len(value) > 0xffffff {
+			return nil, fmt.Errorf("TLV value of %d bytes is too long", len(value))
+		}
+		typ := byte(t.Kind) << 6
+		var header []byte
+		if t.ID > 0xff {
+			typ |= 0x20
+			header = append(header, byte(t.ID>>8), byte(t.ID))
+		} else {
+			header = append(header, byte(t.ID))
+		}
+		switch l := len(value); {
+		case l < 8:
+			typ |= byte(l)
+		case l <= 0xff:
+			typ |= 0x08
+			header = append(header, byte(l))
+		case l <= 0xffff:
+			typ |= 0x10
+			header = append(header, byte(l>>8), byte(l))
+		default:
+			typ |= 0x18
+			header = append(header, byte(l>>16), byte(l>>8), byte(l))
+		}
+		out = append(out, typ)
+		out = append(out, header...)
+		out = append(out, value...)
+	}
+	return out, nil
+}
+
+// decodeTLVResources flattens a TLV payload read from base into resources.
+func decodeTLVResources(data []byte, base Path, reg *Registry) ([]Resource, error) {
+	tlvs, err := DecodeTLV(data)
+	if err != nil {
+		return nil, err
+	}
+	var resources []Resource
+	var walk func(tlvs []TLV, path Path) error
+	walk = func(tlvs []TLV, path Path) error {
+		for _, t := range tlvs {
+			var p Path
+			switch {
+			case t.Kind == TLVObjectInstance && len(path) == 1:
+				p = path.Append(t.ID)
+			case t.Kind == TLVMultipleResource && len(path) == 2, t.Kind == TLVResource && len(path) == 2:
+				p = path.Append(t.ID)
+			case t.Kind == TLVResourceInstance && len(path) == 3:
+				p = path.Append(t.ID)
+			case (t.Kind == TLVMultipleResource || t.Kind == TLVResource) && len(path) == 3 && path[2] == t.ID,
+				t.Kind == TLVObjectInstance && len(path) >= 2 && path[1] == t.ID,
+				t.Kind == TLVResourceInstance && len(path) == 4 && path[3] == t.ID:
+				// the entry repeats the last id of the path that was read
+				p = path
+			default:
+				return fmt.Errorf("unexpected TLV entry of kind %d with id %d below %s", t.Kind, t.ID, path)
+			}
+			if t.Kind == TLVObjectInstance || t.Kind == TLVMultipleResource {
+				if err := walk(t.Children, p); err != nil {
+					return err
+				}
+				continue
+			}
+			v, err := decodeBinary(t.Value, reg.Resource(p[0], p[2]).typeOrDefault(TypeOpaque))
+			if err != nil {
+				return fmt.Errorf("invalid value of %s: %v", p, err)
+			}
+			resources = append(resources, Resource{Path: p, Value: v})
+		}
+		return nil
+	}
+	if len(base) == 0 {
+		return nil, fmt.Errorf("TLV payloads must be read from an object or below")
+	}
+	if err := walk(tlvs, base); err != nil {
+		return nil, err
+	}
+	return resources, nil
+}
+
+// encodeTLVResources builds the TLV tree for resources below base.
+func encodeTLVResources(resources []Resource, base Path) ([]byte, error) {
+	if len(base) == 0 {
+		return nil, fmt.Errorf("TLV payloads must be written to an object or below")
+	}
+	for _, r := range resources {
+		if len(r.Path) < 3 || len(r.Path) < len(base) || !r.Path[:len(base)].equal(base) {
+			return nil, fmt.Errorf("resource %s is not below %s", r.Path, base)
+		}
+	}
+	tlvs, err := buildTLV(resources, len(base))
+	if err != nil {
+		return nil, err
+	}
+	if len(base) == 3 && len(tlvs) > 0 && tlvs[0].Kind == TLVResourceInstance {
+		// the instances of a multiple resource are wrapped in the resource
+		tlvs = []TLV{{Kind: TLVMultipleResource, ID: base[2], Children: tlvs}}
+	}
+	return EncodeTLV(tlvs)
+}
+
+// buildTLV groups resources by their id at level, keeping the order of first appearance.
+func buildTLV(resources []Resource, level int) ([]TLV, error) {
+	var tlvs []TLV
+	index := make(map[uint16]int)
+	groups := make(map[uint16][]Resource)
+	for _, r := range resources {
+		if level >= len(r.Path) {
+			// the resource is the path that is written, e.g. a single resource
+			v, err := encodeBinary(r.Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %v", r.Path, err)
+			}
+			kind := TLVResource
+			if len(r.Path) == 4 {
+				kind = TLVResourceInstance
+			}
+			tlvs = append(tlvs, TLV{Kind: kind, ID: r.Path[len(r.Path)-1], Value: v})
+			continue
+		}
+		id := r.Path[level]
+		if _, ok := index[id]; !ok {
+			index[id] = len(tlvs)
+			tlvs = append(tlvs, TLV{ID: id})
+		}
+		groups[id] = append(groups[id], r)
+	}
+	for id, i := range index {
+		group := groups[id]
+		switch level {
+		case 1:
+			children, err := buildTLV(group, 2)
+			if err != nil {
+				return nil, err
+			}
+			tlvs[i].Kind, tlvs[i].Children = TLVObjectInstance, children
+		case 2:
+			if len(group) == 1 && len(group[0].Path) == 3 {
+				v, err := encodeBinary(group[0].Value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid value of %s: %v", group[0].Path, err)
+				}
+				tlvs[i].Kind, tlvs[i].Value = TLVResource, v
+				continue
+			}
+			children, err := buildTLV(group, 3)
+			if err != nil {
+				return nil, err
+			}
+			tlvs[i].Kind, tlvs[i].Children = TLVMultipleResource, children
+		case 3:
+			v, err := encodeBinary(group[0].Value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value of %s: %v", group[0].Path, err)
+			}
+			tlvs[i].Kind, tlvs[i].Value = TLVResourceInstance, v
+		}
+	}
+	return tlvs, nil
+}
+
+func (p Path) equal(q Path) bool {
+	if len(p) != len(q) {
+		return false
+	}
+	for i := range p {
+		if p[i] != q[i] {
+			return false
+		}
+	}
+	return true
+}
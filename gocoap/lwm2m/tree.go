@@ -0,0 +1,78 @@
+package lwm2m
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WriteTree prints resources as an object/instance/resource tree, naming objects and
+// resources from the registry. reg may be nil.
+//
+//	/3 Device
+//	  /3/0
+//	    /3/0/0 Manufacturer: Acme
+//	    /3/0/7 Power Source Voltage
+//	      /3/0/7/0: 3800
+func WriteTree(w io.Writer, resources []Resource, reg *Registry) error {
+	sorted := append([]Resource(nil), resources...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Path.Less(sorted[j].Path) })
+
+	var last Path
+	for _, r := range sorted {
+		// print the headings of every level that differs from the previous resource
+		common := 0
+		for common < len(last) && common < len(r.Path)-1 && last[common] == r.Path[common] {
+			common++
+		}
+		for level := common; level < len(r.Path)-1; level++ {
+			if _, err := fmt.Fprintf(w, "%s%s%s\n", indent(level), r.Path[:level+1], heading(r.Path[:level+1], reg)); err != nil {
+				return err
+			}
+		}
+		level := len(r.Path) - 1
+		name := ""
+		if level == 2 {
+			name = heading(r.Path, reg)
+		}
+		if _, err := fmt.Fprintf(w, "%s%s%s: %s\n", indent(level), r.Path, name, formatValue(r.Value)); err != nil {
+			return err
+		}
+		last = r.Path
+	}
+	return nil
+}
+
+func indent(level int) string {
+	return strings.Repeat("  ", level)
+}
+
+// heading returns the name of an object or resource with a leading space, or "".
+func heading(p Path, reg *Registry) string {
+	switch len(p) {
+	case 1:
+		if o := reg.Object(p[0]); o != nil {
+			return " " + o.Name
+		}
+	case 3:
+		if r := reg.Resource(p[0], p[2]); r != nil {
+			return " " + r.Name
+		}
+	}
+	return ""
+}
+
+// formatValue formats a value for display.
+func formatValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return fmt.Sprintf("%q", v)
+	case []byte:
+		return fmt.Sprintf("0x%x", v)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(v)
+}
@@ -0,0 +1,211 @@
+package lwm2m
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is the data type of a resource.
+type Type int
+
+const (
+	TypeNone Type = iota
+	TypeString
+	TypeInteger
+	TypeUnsignedInteger
+	TypeFloat
+	TypeBoolean
+	TypeOpaque
+	TypeTime
+	TypeObjLnk
+)
+
+var typeNames = map[Type]string{
+	TypeNone:            "None",
+	TypeString:          "String",
+	TypeInteger:         "Integer",
+	TypeUnsignedInteger: "Unsigned Integer",
+	TypeFloat:           "Float",
+	TypeBoolean:         "Boolean",
+	TypeOpaque:          "Opaque",
+	TypeTime:            "Time",
+	TypeObjLnk:          "Objlnk",
+}
+
+func (t Type) String() string {
+	if name, ok := typeNames[t]; ok {
+		return name
+	}
+	return "Type(" + strconv.Itoa(int(t)) + ")"
+}
+
+// decodeBinary converts a TLV value to the Go value for t.
+func decodeBinary(b []byte, t Type) (any, error) {
+	switch t {
+	case TypeString:
+		return string(b), nil
+	case TypeInteger, TypeTime:
+		var v int64
+		switch len(b) {
+		case 1:
+			v = int64(int8(b[0]))
+		case 2:
+			v = int64(int16(binary.BigEndian.Uint16(b)))
+		case 4:
+			v = int64(int32(binary.BigEndian.Uint32(b)))
+		case 8:
+			v = int64(binary.BigEndian.Uint64(b))
+		default:
+			return nil, fmt.Errorf("invalid integer length %d", len(b))
+		}
+		if t == TypeTime {
+			return time.Unix(v, 0).UTC(), nil
+		}
+		return v, nil
+	case TypeUnsignedInteger:
+		switch len(b) {
+		case 1, 2, 4, 8:
+			var v uint64
+			for _, c := range b {
+				v = v<<8 | uint64(c)
+			}
+			return v, nil
+		}
+		return nil, fmt.Errorf("invalid unsigned integer length %d", len(b))
+	case TypeFloat:
+		switch len(b) {
+		case 4:
+			return float64(math.Float32frombits(binary.BigEndian.Uint32(b))), nil
+		case 8:
+			return math.Float64frombits(binary.BigEndian.Uint64(b)), nil
+		}
+		return nil, fmt.Errorf("invalid float length %d", len(b))
+	case TypeBoolean:
+		if len(b) != 1 || b[0] > 1 {
+			return nil, fmt.Errorf("invalid boolean %x", b)
+		}
+		return b[0] == 1, nil
+	case TypeObjLnk:
+		if len(b) != 4 {
+			return nil, fmt.Errorf("invalid objlnk length %d", len(b))
+		}
+		return ObjLnk{Object: binary.BigEndian.Uint16(b), Instance: binary.BigEndian.Uint16(b[2:])}, nil
+	}
+	return append([]byte(nil), b...), nil
+}
+
+// encodeBinary converts a Go value to its TLV representation.
+// Integers use the shortest of 1, 2, 4 or 8 bytes and floats 8 bytes.
+func encodeBinary(v any) ([]byte, error) {
+	switch v := v.(type) {
+	case string:
+		return []byte(v), nil
+	case int:
+		return encodeInt(int64(v)), nil
+	case int64:
+		return encodeInt(v), nil
+	case uint64:
+		switch {
+		case v <= math.MaxUint8:
+			return []byte{byte(v)}, nil
+		case v <= math.MaxUint16:
+			return binary.BigEndian.AppendUint16(nil, uint16(v)), nil
+		case v <= math.MaxUint32:
+			return binary.BigEndian.AppendUint32(nil, uint32(v)), nil
+		}
+		return binary.BigEndian.AppendUint64(nil, v), nil
+	case float64:
+		return binary.BigEndian.AppendUint64(nil, math.Float64bits(v)), nil
+	case bool:
+		if v {
+			return []byte{1}, nil
+		}
+		return []byte{0}, nil
+	case time.Time:
+		return encodeInt(v.Unix()), nil
+	case []byte:
+		return v, nil
+	case ObjLnk:
+		return binary.BigEndian.AppendUint16(binary.BigEndian.AppendUint16(nil, v.Object), v.Instance), nil
+	case nil:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("unsupported value type %T", v)
+}
+
+func encodeInt(v int64) []byte {
+	switch {
+	case v >= math.MinInt8 && v <= math.MaxInt8:
+		return []byte{byte(v)}
+	case v >= math.MinInt16 && v <= math.MaxInt16:
+		return binary.BigEndian.AppendUint16(nil, uint16(v))
+	case v >= math.MinInt32 && v <= math.MaxInt32:
+		return binary.BigEndian.AppendUint32(nil, uint32(v))
+	}
+	return binary.BigEndian.AppendUint64(nil, uint64(v))
+}
+
+// parseText converts a plain text value to the Go value for t.
+func parseText(s string, t Type) (any, error) {
+	switch t {
+	case TypeInteger:
+		return strconv.ParseInt(s, 10, 64)
+	case TypeUnsignedInteger:
+		return strconv.ParseUint(s, 10, 64)
+	case TypeFloat:
+		return strconv.ParseFloat(s, 64)
+	case TypeTime:
+		v, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		return time.Unix(v, 0).UTC(), nil
+	case TypeBoolean:
+		switch s {
+		case "0":
+			return false, nil
+		case "1":
+			return true, nil
+		}
+		return nil, fmt.Errorf("invalid boolean %q", s)
+	case TypeObjLnk:
+		return parseObjLnk(s)
+	case TypeOpaque:
+		return nil, fmt.Errorf("opaque resources have no plain text representation")
+	}
+	return s, nil
+}
+
+func parseObjLnk(s string) (ObjLnk, error) {
+	obj, inst, ok := strings.Cut(s, ":")
+	o, err1 := strconv.ParseUint(obj, 10, 16)
+	i, err2 := strconv.ParseUint(inst, 10, 16)
+	if !ok || err1 != nil || err2 != nil {
+		return ObjLnk{}, fmt.Errorf("invalid objlnk %q", s)
+	}
+	return ObjLnk{Object: uint16(o), Instance: uint16(i)}, nil
+}
+
+// formatText formats a value as plain text; booleans are 0 or 1 and times are Unix seconds.
+func formatText(v any) string {
+	switch v := v.(type) {
+	case bool:
+		if v {
+			return "1"
+		}
+		return "0"
+	case time.Time:
+		return strconv.FormatInt(v.Unix(), 10)
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64)
+	case []byte:
+		return fmt.Sprintf("%x", v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
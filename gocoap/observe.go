@@ -0,0 +1,74 @@
+// Package gocoap provides functionality for interacting with CoAP servers.
+package gocoap
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message/pool"
+)
+
+// Observe registers an observation (RFC 7641) of url and calls notify with the first
+// response and every notification that follows, until ctx is done or the server ends the
+// observation with a response that is not a notification. The observation is cancelled
+// before Observe returns. Calls of notify do not overlap.
+func (c *Client) Observe(ctx context.Context, url string, notify func(resp *Response)) error {
+	uri, err := ParseURI(url)
+	if err != nil {
+		return err
+	}
+	conn, err := c.dial(uri)
+	if err != nil {
+		return err
+	}
+	defer connClose(conn)
+
+	done := make(chan struct{})
+	var mu sync.Mutex
+	var ended bool
+	first := true
+	start := time.Now()
+	handler := func(msg *pool.Message) {
+		mu.Lock()
+		defer mu.Unlock()
+		if ended {
+			return
+		}
+		resp, err := readResponse(msg)
+		if err != nil {
+			return
+		}
+		resp.Peer = conn.RemoteAddr().String()
+		if first {
+			resp.RTT = resp.Received.Sub(start)
+			first = false
+		}
+		notify(resp)
+		if !resp.HasObserve {
+			ended = true
+			close(done)
+		}
+	}
+
+	regCtx, cancel := context.WithTimeout(ctx, c.timeout)
+	obs, err := conn.Observe(regCtx, "", handler, c.requestOptions(uri)...)
+	cancel()
+	if err != nil {
+		return fmt.Errorf("failed to observe: %v", err)
+	}
+
+	// an observation ended by the server needs no cancellation
+	select {
+	case <-ctx.Done():
+	case <-done:
+		return nil
+	}
+	if !obs.Canceled() {
+		cancelCtx, cancel := context.WithTimeout(context.Background(), c.timeout)
+		defer cancel()
+		_ = obs.Cancel(cancelCtx)
+	}
+	return nil
+}
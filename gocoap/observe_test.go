@@ -0,0 +1,94 @@
+package gocoap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/mux"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/options"
+	coapudp "github.com/plgd-dev/go-coap/v3/udp"
+)
+
+// startObserveServer serves /counter, which sends three notifications and then ends the observation.
+func startObserveServer(t *testing.T) string {
+	send := func(cc mux.Conn, token []byte, obs int64, body string) error {
+		m := cc.AcquireMessage(cc.Context())
+		defer cc.ReleaseMessage(m)
+		m.SetCode(codes.Content)
+		m.SetToken(token)
+		m.SetContentFormat(message.TextPlain)
+		m.SetBody(bytes.NewReader([]byte(body)))
+		if obs >= 0 {
+			m.SetObserve(uint32(obs))
+		}
+		return cc.WriteMessage(m)
+	}
+	router := mux.NewRouter()
+	err := router.Handle("/counter", mux.HandlerFunc(func(w mux.ResponseWriter, r *mux.Message) {
+		if obs, err := r.Options().Observe(); err != nil || obs != 0 {
+			return
+		}
+		token := append([]byte(nil), r.Token()...)
+		cc := w.Conn()
+		go func() {
+			for i := int64(0); i < 3; i++ {
+				if err := send(cc, token, i+2, fmt.Sprint(i)); err != nil {
+					return
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+			_ = send(cc, token, -1, "done")
+		}()
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := coapnet.NewListenUDP("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := coapudp.NewServer(options.WithMux(router))
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(func() {
+		s.Stop()
+		_ = l.Close()
+	})
+	return "coap://" + l.LocalAddr().String() + "/counter"
+}
+
+func TestObserve(t *testing.T) {
+	url := startObserveServer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	var got []*Response
+	err := NewClient(time.Second).Observe(ctx, url, func(resp *Response) {
+		got = append(got, resp)
+	})
+	if err != nil {
+		t.Fatalf("TestObserve: expected no error, got %s", err)
+	}
+	if ctx.Err() != nil {
+		t.Fatalf("TestObserve: expected the observation to end before the timeout")
+	}
+	if len(got) != 4 {
+		t.Fatalf("TestObserve: expected 4 responses, got %d", len(got))
+	}
+	for i, resp := range got[:3] {
+		if !resp.HasObserve || resp.Observe != uint32(i+2) || string(resp.Body) != fmt.Sprint(i) {
+			t.Errorf("TestObserve: unexpected notification %d: %+v", i, resp)
+		}
+	}
+	if got[3].HasObserve || string(got[3].Body) != "done" {
+		t.Errorf("TestObserve: expected a final response without observe, got %+v", got[3])
+	}
+	if got[0].RTT <= 0 || got[1].RTT != 0 || got[0].Peer == "" {
+		t.Errorf("TestObserve: expected the first notification only to have an RTT and all a peer, got %v %v %q", got[0].RTT, got[1].RTT, got[0].Peer)
+	}
+}
@@ -0,0 +1,105 @@
+package gocoap
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/options"
+)
+
+// Ping sends a CoAP ping, an empty confirmable message, to the host of url and waits for
+// the reset that answers it. It returns the round-trip time. The path of url is ignored.
+//
+// Like an ICMP echo the ping is sent once and not retransmitted, so a lost message or
+// reset fails the ping after the client timeout.
+func (c *Client) Ping(url string) (time.Duration, error) {
+	uri, err := ParseURI(url)
+	if err != nil {
+		return 0, err
+	}
+	// go-coap retransmits confirmable messages, the ping included, after the ACK timeout and
+	// drops the handler of a message as soon as it may not be retransmitted anymore. An ACK
+	// timeout past the ping timeout keeps the handler without ever sending the ping again.
+	conn, err := c.dial(uri, options.WithTransmission(1, 2*c.timeout, 1))
+	if err != nil {
+		return 0, err
+	}
+	defer connClose(conn)
+
+	received := make(chan time.Time, 1)
+	start := time.Now()
+	remove, err := conn.AsyncPing(func() {
+		select {
+		case received <- time.Now():
+		default:
+		}
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to send ping: %v", err)
+	}
+	defer remove()
+
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+	select {
+	case t := <-received:
+		return t.Sub(start), nil
+	case <-timer.C:
+		return 0, fmt.Errorf("no reset from %s within %v", uri.Address(), c.timeout)
+	}
+}
+
+// PingStats summarizes a series of pings.
+type PingStats struct {
+	Sent int
+	// RTTs holds the round-trip times of the answered pings.
+	RTTs []time.Duration
+}
+
+// Add records the result of a ping.
+func (s *PingStats) Add(rtt time.Duration, err error) {
+	s.Sent++
+	if err == nil {
+		s.RTTs = append(s.RTTs, rtt)
+	}
+}
+
+// Received returns the number of answered pings.
+func (s *PingStats) Received() int {
+	return len(s.RTTs)
+}
+
+// Loss returns the percentage of pings that were not answered.
+func (s *PingStats) Loss() float64 {
+	if s.Sent == 0 {
+		return 0
+	}
+	return float64(s.Sent-len(s.RTTs)) * 100 / float64(s.Sent)
+}
+
+// Summary returns the minimum, average, maximum and standard deviation of the round-trip times.
+// All are zero when no ping was answered.
+func (s *PingStats) Summary() (min, avg, max, stddev time.Duration) {
+	if len(s.RTTs) == 0 {
+		return 0, 0, 0, 0
+	}
+	min, max = s.RTTs[0], s.RTTs[0]
+	var sum float64
+	for _, rtt := range s.RTTs {
+		if rtt < min {
+			min = rtt
+		}
+		if rtt > max {
+			max = rtt
+		}
+		sum += float64(rtt)
+	}
+	mean := sum / float64(len(s.RTTs))
+	var squares float64
+	for _, rtt := range s.RTTs {
+		d := float64(rtt) - mean
+		squares += d * d
+	}
+	return min, time.Duration(mean), max, time.Duration(math.Sqrt(squares / float64(len(s.RTTs))))
+}
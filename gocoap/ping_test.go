@@ -0,0 +1,80 @@
+package gocoap
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/larryr/tools/gocoap/coaptest"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+)
+
+func TestPing(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	client := NewClient(200 * time.Millisecond)
+
+	rtt, err := client.Ping(srv.URL)
+	if err != nil {
+		t.Fatalf("TestPing: expected no error, got %v", err)
+	}
+	if rtt <= 0 || rtt > 200*time.Millisecond {
+		t.Errorf("TestPing: expected a round-trip time below the timeout, got %v", rtt)
+	}
+
+	// a lost ping is not retransmitted
+	srv.DropNext(1)
+	if _, err := client.Ping(srv.URL); err == nil {
+		t.Errorf("TestPing: expected an error for a dropped ping")
+	}
+	requests := srv.Requests()
+	if len(requests) != 2 {
+		t.Fatalf("TestPing: expected 2 datagrams, got %d", len(requests))
+	}
+	if requests[0].Code != codes.Empty || len(requests[0].Token) != 0 || !requests[1].Dropped {
+		t.Errorf("TestPing: unexpected requests %+v", requests)
+	}
+}
+
+func TestPingNotRetransmitted(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	// longer than the 2s ACK timeout, after which a confirmable message is retransmitted
+	client := NewClient(3 * time.Second)
+
+	srv.DropNext(1)
+	if _, err := client.Ping(srv.URL); err == nil {
+		t.Errorf("TestPingNotRetransmitted: expected an error for a dropped ping")
+	}
+	if requests := srv.Requests(); len(requests) != 1 || !requests[0].Dropped {
+		t.Errorf("TestPingNotRetransmitted: expected the dropped ping only, got %+v", requests)
+	}
+}
+
+func TestPingStats(t *testing.T) {
+	var s PingStats
+	s.Add(2*time.Millisecond, nil)
+	s.Add(4*time.Millisecond, nil)
+	s.Add(0, errors.New("no reset"))
+	s.Add(6*time.Millisecond, nil)
+
+	if s.Sent != 4 || s.Received() != 3 {
+		t.Errorf("TestPingStats: expected 4 sent and 3 received, got %d and %d", s.Sent, s.Received())
+	}
+	if s.Loss() != 25 {
+		t.Errorf("TestPingStats: expected 25%% loss, got %v", s.Loss())
+	}
+	min, avg, max, stddev := s.Summary()
+	if min != 2*time.Millisecond || avg != 4*time.Millisecond || max != 6*time.Millisecond {
+		t.Errorf("TestPingStats: expected min/avg/max 2ms/4ms/6ms, got %v/%v/%v", min, avg, max)
+	}
+	// sqrt((4+0+4)/3) ms
+	if stddev < 1632*time.Microsecond || stddev > 1633*time.Microsecond {
+		t.Errorf("TestPingStats: expected a standard deviation of 1.633ms, got %v", stddev)
+	}
+
+	var empty PingStats
+	if empty.Loss() != 0 {
+		t.Errorf("TestPingStats: expected no loss without pings, got %v", empty.Loss())
+	}
+}
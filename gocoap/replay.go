@@ -0,0 +1,189 @@
+// Package gocoap provides functionality for interacting with CoAP servers.
+package gocoap
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/mux"
+)
+
+// Notification is one recorded notification of an observe session.
+// A recording is a file with one Notification JSON object per line.
+type Notification struct {
+	Time          time.Time          `json:"time"`
+	Path          string             `json:"path"`
+	Code          codes.Code         `json:"code"`
+	ContentFormat *message.MediaType `json:"content_format,omitempty"`
+	Observe       uint32             `json:"observe"`
+	Payload       []byte             `json:"payload,omitempty"`
+}
+
+// Recorder writes the notifications of an observe session to a recording.
+type Recorder struct {
+	enc  *json.Encoder
+	path string
+}
+
+// NewRecorder returns a Recorder that writes notifications for url to w.
+func NewRecorder(w io.Writer, url string) (*Recorder, error) {
+	uri, err := ParseURI(url)
+	if err != nil {
+		return nil, err
+	}
+	return &Recorder{enc: json.NewEncoder(w), path: uri.PathString()}, nil
+}
+
+// Record appends a response to the recording.
+func (r *Recorder) Record(resp *Response) error {
+	n := Notification{
+		Time:    resp.Received,
+		Path:    r.path,
+		Code:    resp.Code,
+		Observe: resp.Observe,
+		Payload: resp.Body,
+	}
+	if resp.HasContentFormat {
+		cf := resp.ContentFormat
+		n.ContentFormat = &cf
+	}
+	if err := r.enc.Encode(n); err != nil {
+		return fmt.Errorf("failed to write recording: %v", err)
+	}
+	return nil
+}
+
+// ReadRecording reads the notifications of a recording.
+func ReadRecording(r io.Reader) ([]Notification, error) {
+	var notifications []Notification
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for line := 1; scanner.Scan(); line++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var n Notification
+		if err := json.Unmarshal(scanner.Bytes(), &n); err != nil {
+			return nil, fmt.Errorf("failed to read recording line %d: %v", line, err)
+		}
+		notifications = append(notifications, n)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read recording: %v", err)
+	}
+	return notifications, nil
+}
+
+// NewReplayRouter returns a router that serves recorded notifications on their paths.
+// Each observer is sent the notifications of its path with the original spacing in time,
+// starting with the first one as the response to the registration. A GET without Observe
+// is answered with the first notification.
+func NewReplayRouter(notifications []Notification) (*mux.Router, error) {
+	byPath := make(map[string][]Notification)
+	for _, n := range notifications {
+		byPath[n.Path] = append(byPath[n.Path], n)
+	}
+	router := mux.NewRouter()
+	for path, ns := range byPath {
+		r := &replay{notifications: ns, observers: make(map[string]chan struct{})}
+		if err := router.Handle(path, r); err != nil {
+			return nil, fmt.Errorf("failed to register %s: %v", path, err)
+		}
+	}
+	return router, nil
+}
+
+// replay serves the notifications of one path.
+type replay struct {
+	notifications []Notification
+
+	mu sync.Mutex
+	// observers holds a stop channel per observer, keyed by remote address and token
+	observers map[string]chan struct{}
+}
+
+func (r *replay) ServeCOAP(w mux.ResponseWriter, req *mux.Message) {
+	if req.Code() != codes.GET {
+		_ = w.SetResponse(codes.MethodNotAllowed, message.TextPlain, nil)
+		return
+	}
+	obs, err := req.Options().Observe()
+	key := fmt.Sprintf("%s/%x", w.Conn().RemoteAddr(), req.Token())
+	switch {
+	case err != nil:
+		_ = r.send(w.Conn(), req.Token(), r.notifications[0], -1)
+	case obs == 0:
+		stop := make(chan struct{})
+		r.mu.Lock()
+		if old, ok := r.observers[key]; ok {
+			close(old)
+		}
+		r.observers[key] = stop
+		r.mu.Unlock()
+		cc, token := w.Conn(), append([]byte(nil), req.Token()...)
+		go func() {
+			r.play(cc, token, stop)
+			r.mu.Lock()
+			if r.observers[key] == stop {
+				delete(r.observers, key)
+			}
+			r.mu.Unlock()
+		}()
+	default:
+		// deregistration
+		r.mu.Lock()
+		if stop, ok := r.observers[key]; ok {
+			close(stop)
+			delete(r.observers, key)
+		}
+		r.mu.Unlock()
+		_ = r.send(w.Conn(), req.Token(), r.notifications[0], -1)
+	}
+}
+
+// play sends the notifications to one observer until they run out, the observer
+// deregisters or the connection is closed.
+func (r *replay) play(cc mux.Conn, token []byte, stop chan struct{}) {
+	start := time.Now()
+	first := r.notifications[0].Time
+	for i, n := range r.notifications {
+		if wait := n.Time.Sub(first) - time.Since(start); wait > 0 {
+			select {
+			case <-time.After(wait):
+			case <-stop:
+				return
+			case <-cc.Context().Done():
+				return
+			}
+		}
+		// sequence numbers start at 2, as 0 and 1 look like a registration when logged
+		if err := r.send(cc, token, n, int64(i)+2); err != nil {
+			return
+		}
+	}
+}
+
+// send writes a notification, or a plain response if obs is negative.
+func (r *replay) send(cc mux.Conn, token []byte, n Notification, obs int64) error {
+	m := cc.AcquireMessage(cc.Context())
+	defer cc.ReleaseMessage(m)
+	m.SetCode(n.Code)
+	m.SetToken(token)
+	if n.ContentFormat != nil {
+		m.SetContentFormat(*n.ContentFormat)
+	}
+	if obs >= 0 {
+		m.SetObserve(uint32(obs))
+	}
+	if len(n.Payload) > 0 {
+		m.SetBody(bytes.NewReader(n.Payload))
+	}
+	return cc.WriteMessage(m)
+}
@@ -0,0 +1,91 @@
+package gocoap
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/options"
+	coapudp "github.com/plgd-dev/go-coap/v3/udp"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	// record a session with notifications 0, 50 and 150ms after the first
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	buf := &bytes.Buffer{}
+	rec, err := NewRecorder(buf, "coap://device/sensors/temp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, offset := range []time.Duration{0, 50 * time.Millisecond, 150 * time.Millisecond} {
+		resp := &Response{
+			Code:             codes.Content,
+			ContentFormat:    message.TextPlain,
+			HasContentFormat: true,
+			Observe:          uint32(10 + i),
+			HasObserve:       true,
+			Body:             []byte{'a' + byte(i)},
+			Received:         start.Add(offset),
+		}
+		if err := rec.Record(resp); err != nil {
+			t.Fatalf("TestRecordAndReplay: expected no error, got %s", err)
+		}
+	}
+	notifications, err := ReadRecording(buf)
+	if err != nil {
+		t.Fatalf("TestRecordAndReplay: expected no error, got %s", err)
+	}
+	if len(notifications) != 3 || notifications[1].Path != "/sensors/temp" || string(notifications[2].Payload) != "c" {
+		t.Fatalf("TestRecordAndReplay: unexpected recording %+v", notifications)
+	}
+
+	// replay it to an observer
+	router, err := NewReplayRouter(notifications)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := coapnet.NewListenUDP("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := coapudp.NewServer(options.WithMux(router))
+	go func() { _ = s.Serve(l) }()
+	defer func() {
+		s.Stop()
+		_ = l.Close()
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	var mu sync.Mutex
+	var got []*Response
+	err = NewClient(time.Second).Observe(ctx, "coap://"+l.LocalAddr().String()+"/sensors/temp", func(resp *Response) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, resp)
+		if len(got) == 3 {
+			cancel()
+		}
+	})
+	if err != nil {
+		t.Fatalf("TestRecordAndReplay: expected no error, got %s", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(got) != 3 {
+		t.Fatalf("TestRecordAndReplay: expected 3 notifications, got %d", len(got))
+	}
+	for i, resp := range got {
+		if string(resp.Body) != string(rune('a'+i)) || !resp.HasObserve || resp.ContentFormat != message.TextPlain {
+			t.Errorf("TestRecordAndReplay: unexpected notification %d: %+v", i, resp)
+		}
+	}
+	if d := got[2].Received.Sub(got[0].Received); d < 140*time.Millisecond || d > time.Second {
+		t.Errorf("TestRecordAndReplay: expected the original spacing of 150ms, got %s", d)
+	}
+}
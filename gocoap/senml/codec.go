@@ -0,0 +1,135 @@
+package senml
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// jsonRecord is the JSON representation of a record (RFC 8428 section 5).
+type jsonRecord struct {
+	BaseName    string   `json:"bn,omitempty"`
+	BaseTime    float64  `json:"bt,omitempty"`
+	BaseUnit    string   `json:"bu,omitempty"`
+	BaseValue   *float64 `json:"bv,omitempty"`
+	BaseSum     *float64 `json:"bs,omitempty"`
+	BaseVersion *int     `json:"bver,omitempty"`
+	Name        string   `json:"n,omitempty"`
+	Unit        string   `json:"u,omitempty"`
+	Value       *float64 `json:"v,omitempty"`
+	StringValue *string  `json:"vs,omitempty"`
+	BoolValue   *bool    `json:"vb,omitempty"`
+	DataValue   *string  `json:"vd,omitempty"`
+	Sum         *float64 `json:"s,omitempty"`
+	Time        float64  `json:"t,omitempty"`
+	UpdateTime  float64  `json:"ut,omitempty"`
+}
+
+// cborRecord is the CBOR representation of a record using the integer labels of RFC 8428 section 6.
+// Its fields mirror Record so the two convert directly.
+type cborRecord struct {
+	BaseName    string   `cbor:"-2,keyasint,omitempty"`
+	BaseTime    float64  `cbor:"-3,keyasint,omitempty"`
+	BaseUnit    string   `cbor:"-4,keyasint,omitempty"`
+	BaseValue   *float64 `cbor:"-5,keyasint,omitempty"`
+	BaseSum     *float64 `cbor:"-6,keyasint,omitempty"`
+	BaseVersion *int     `cbor:"-1,keyasint,omitempty"`
+	Name        string   `cbor:"0,keyasint,omitempty"`
+	Unit        string   `cbor:"1,keyasint,omitempty"`
+	Value       *float64 `cbor:"2,keyasint,omitempty"`
+	StringValue *string  `cbor:"3,keyasint,omitempty"`
+	BoolValue   *bool    `cbor:"4,keyasint,omitempty"`
+	DataValue   []byte   `cbor:"8,keyasint,omitempty"`
+	Sum         *float64 `cbor:"5,keyasint,omitempty"`
+	Time        float64  `cbor:"6,keyasint,omitempty"`
+	UpdateTime  float64  `cbor:"7,keyasint,omitempty"`
+}
+
+// DecodeJSON parses an application/senml+json payload.
+func DecodeJSON(data []byte) (Pack, error) {
+	var records []jsonRecord
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode SenML JSON: %v", err)
+	}
+	p := make(Pack, 0, len(records))
+	for i, jr := range records {
+		r := Record{
+			BaseName:    jr.BaseName,
+			BaseTime:    jr.BaseTime,
+			BaseUnit:    jr.BaseUnit,
+			BaseValue:   jr.BaseValue,
+			BaseSum:     jr.BaseSum,
+			BaseVersion: jr.BaseVersion,
+			Name:        jr.Name,
+			Unit:        jr.Unit,
+			Value:       jr.Value,
+			StringValue: jr.StringValue,
+			BoolValue:   jr.BoolValue,
+			Sum:         jr.Sum,
+			Time:        jr.Time,
+			UpdateTime:  jr.UpdateTime,
+		}
+		if jr.DataValue != nil {
+			data, err := base64.RawURLEncoding.DecodeString(*jr.DataValue)
+			if err != nil {
+				return nil, fmt.Errorf("record %d: invalid data value: %v", i, err)
+			}
+			r.DataValue = data
+		}
+		p = append(p, r)
+	}
+	return p, nil
+}
+
+// EncodeJSON serializes the pack as application/senml+json.
+func EncodeJSON(p Pack) ([]byte, error) {
+	records := make([]jsonRecord, 0, len(p))
+	for _, r := range p {
+		jr := jsonRecord{
+			BaseName:    r.BaseName,
+			BaseTime:    r.BaseTime,
+			BaseUnit:    r.BaseUnit,
+			BaseValue:   r.BaseValue,
+			BaseSum:     r.BaseSum,
+			BaseVersion: r.BaseVersion,
+			Name:        r.Name,
+			Unit:        r.Unit,
+			Value:       r.Value,
+			StringValue: r.StringValue,
+			BoolValue:   r.BoolValue,
+			Sum:         r.Sum,
+			Time:        r.Time,
+			UpdateTime:  r.UpdateTime,
+		}
+		if r.DataValue != nil {
+			s := base64.RawURLEncoding.EncodeToString(r.DataValue)
+			jr.DataValue = &s
+		}
+		records = append(records, jr)
+	}
+	return json.Marshal(records)
+}
+
+// DecodeCBOR parses an application/senml+cbor payload.
+func DecodeCBOR(data []byte) (Pack, error) {
+	var records []cborRecord
+	if err := cbor.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to decode SenML CBOR: %v", err)
+	}
+	p := make(Pack, 0, len(records))
+	for _, cr := range records {
+		p = append(p, Record(cr))
+	}
+	return p, nil
+}
+
+// EncodeCBOR serializes the pack as application/senml+cbor.
+func EncodeCBOR(p Pack) ([]byte, error) {
+	records := make([]cborRecord, 0, len(p))
+	for _, r := range p {
+		records = append(records, cborRecord(r))
+	}
+	return cbor.Marshal(records)
+}
@@ -0,0 +1,217 @@
+// Package senml implements Sensor Measurement Lists (RFC 8428) in their JSON and CBOR
+// representations, including resolution of base fields into self-contained records.
+package senml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+)
+
+// SenML content formats registered for CoAP.
+// The streaming (sensml) variants share the same encoding as their senml counterparts.
+const (
+	ContentFormatJSON       message.MediaType = 110 // application/senml+json
+	ContentFormatSensmlJSON message.MediaType = 111 // application/sensml+json
+	ContentFormatCBOR       message.MediaType = 112 // application/senml+cbor
+	ContentFormatSensmlCBOR message.MediaType = 113 // application/sensml+cbor
+)
+
+// relativeTimeLimit is the threshold below which a resolved time is relative to now (RFC 8428 section 4.5.3).
+const relativeTimeLimit = 1 << 28
+
+// Record is a single SenML record. Optional values are pointers so that an absent
+// field can be told apart from a zero value.
+type Record struct {
+	BaseName    string
+	BaseTime    float64
+	BaseUnit    string
+	BaseValue   *float64
+	BaseSum     *float64
+	BaseVersion *int
+
+	Name        string
+	Unit        string
+	Value       *float64
+	StringValue *string
+	BoolValue   *bool
+	DataValue   []byte
+	Sum         *float64
+	Time        float64
+	UpdateTime  float64
+}
+
+// Pack is an ordered list of SenML records.
+type Pack []Record
+
+// IsSenML returns true if the content format is one of the SenML JSON or CBOR formats.
+func IsSenML(cf message.MediaType) bool {
+	switch cf {
+	case ContentFormatJSON, ContentFormatSensmlJSON, ContentFormatCBOR, ContentFormatSensmlCBOR:
+		return true
+	}
+	return false
+}
+
+// Decode parses a SenML payload encoded in the given content format.
+func Decode(data []byte, cf message.MediaType) (Pack, error) {
+	switch cf {
+	case ContentFormatJSON, ContentFormatSensmlJSON:
+		return DecodeJSON(data)
+	case ContentFormatCBOR, ContentFormatSensmlCBOR:
+		return DecodeCBOR(data)
+	}
+	return nil, fmt.Errorf("not a SenML content format: %v", cf)
+}
+
+// Encode serializes the pack using the given content format.
+func Encode(p Pack, cf message.MediaType) ([]byte, error) {
+	switch cf {
+	case ContentFormatJSON, ContentFormatSensmlJSON:
+		return EncodeJSON(p)
+	case ContentFormatCBOR, ContentFormatSensmlCBOR:
+		return EncodeCBOR(p)
+	}
+	return nil, fmt.Errorf("not a SenML content format: %v", cf)
+}
+
+// Convert re-encodes a SenML payload from one content format to another, e.g. JSON to CBOR.
+func Convert(data []byte, from, to message.MediaType) ([]byte, error) {
+	p, err := Decode(data, from)
+	if err != nil {
+		return nil, err
+	}
+	return Encode(p, to)
+}
+
+// Resolve applies the base fields of the pack to every record and returns the resolved
+// records as defined in RFC 8428 section 4.6. Resolved records carry no base fields,
+// have a full name, an absolute time and a unit if one was given.
+// Times smaller than 2**28 are interpreted relative to now.
+func (p Pack) Resolve(now time.Time) (Pack, error) {
+	var baseName, baseUnit string
+	var baseTime, baseValue, baseSum float64
+	result := make(Pack, 0, len(p))
+	for i, r := range p {
+		if r.BaseVersion != nil && *r.BaseVersion > 10 {
+			return nil, fmt.Errorf("record %d: unsupported SenML version %d", i, *r.BaseVersion)
+		}
+		if r.BaseName != "" {
+			baseName = r.BaseName
+		}
+		if r.BaseTime != 0 {
+			baseTime = r.BaseTime
+		}
+		if r.BaseUnit != "" {
+			baseUnit = r.BaseUnit
+		}
+		if r.BaseValue != nil {
+			baseValue = *r.BaseValue
+		}
+		if r.BaseSum != nil {
+			baseSum = *r.BaseSum
+		}
+
+		resolved := Record{
+			Name:        baseName + r.Name,
+			Unit:        r.Unit,
+			StringValue: r.StringValue,
+			BoolValue:   r.BoolValue,
+			DataValue:   r.DataValue,
+			Time:        baseTime + r.Time,
+			UpdateTime:  r.UpdateTime,
+		}
+		if resolved.Unit == "" {
+			resolved.Unit = baseUnit
+		}
+		if r.Value != nil {
+			v := baseValue + *r.Value
+			resolved.Value = &v
+		}
+		if r.Sum != nil {
+			s := baseSum + *r.Sum
+			resolved.Sum = &s
+		}
+		if resolved.Time < relativeTimeLimit {
+			resolved.Time += timeToSenML(now)
+		}
+		if err := resolved.validate(); err != nil {
+			return nil, fmt.Errorf("record %d: %v", i, err)
+		}
+		result = append(result, resolved)
+	}
+	return result, nil
+}
+
+// validate checks a resolved record for a legal name and exactly one value.
+func (r *Record) validate() error {
+	if r.Name == "" {
+		return errors.New("missing name")
+	}
+	if !validName(r.Name) {
+		return fmt.Errorf("invalid name %q", r.Name)
+	}
+	values := 0
+	if r.Value != nil {
+		values++
+	}
+	if r.StringValue != nil {
+		values++
+	}
+	if r.BoolValue != nil {
+		values++
+	}
+	if r.DataValue != nil {
+		values++
+	}
+	if values > 1 {
+		return fmt.Errorf("%s: more than one value", r.Name)
+	}
+	if values == 0 && r.Sum == nil {
+		return fmt.Errorf("%s: no value or sum", r.Name)
+	}
+	return nil
+}
+
+// validName reports whether the name only uses the characters allowed by RFC 8428 section 4.5.1.
+func validName(name string) bool {
+	for i, c := range name {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case i > 0 && (c == '-' || c == ':' || c == '.' || c == '/' || c == '_'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Timestamp returns the record time as a time.Time. It is only meaningful on resolved records.
+func (r *Record) Timestamp() time.Time {
+	sec, frac := math.Modf(r.Time)
+	return time.Unix(int64(sec), int64(frac*1e9)).UTC()
+}
+
+// ValueString returns the value of the record formatted for display.
+func (r *Record) ValueString() string {
+	switch {
+	case r.Value != nil:
+		return fmt.Sprintf("%g", *r.Value)
+	case r.StringValue != nil:
+		return *r.StringValue
+	case r.BoolValue != nil:
+		return fmt.Sprintf("%t", *r.BoolValue)
+	case r.DataValue != nil:
+		return fmt.Sprintf("%x", r.DataValue)
+	case r.Sum != nil:
+		return fmt.Sprintf("sum=%g", *r.Sum)
+	}
+	return ""
+}
+
+func timeToSenML(t time.Time) float64 {
+	return float64(t.UnixNano()) / 1e9
+}
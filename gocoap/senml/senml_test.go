@@ -0,0 +1,153 @@
+package senml
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// example from RFC 8428 section 5.1.2
+const multipleDataPoints = `[
+     {"bn":"urn:dev:ow:10e2073a01080063:","bt":1.320067464e+09,
+      "bu":"%RH","v":20},
+     {"u":"lon","v":24.30621},
+     {"u":"lat","v":60.07965},
+     {"t":60,"v":20.3},
+     {"u":"lon","t":60,"v":24.30622},
+     {"u":"lat","t":60,"v":60.07965},
+     {"t":120,"v":20.7},
+     {"u":"lon","t":120,"v":24.30623},
+     {"u":"lat","t":120,"v":60.07966},
+     {"u":"%EL","t":150,"v":98},
+     {"t":180,"v":21.2},
+     {"u":"lon","t":180,"v":24.30628},
+     {"u":"lat","t":180,"v":60.07967}
+   ]`
+
+func TestResolve(t *testing.T) {
+	p, err := DecodeJSON([]byte(multipleDataPoints))
+	if err != nil {
+		t.Fatalf("TestResolve: expected no error, got %s", err)
+	}
+	resolved, err := p.Resolve(time.Now())
+	if err != nil {
+		t.Fatalf("TestResolve: expected no error, got %s", err)
+	}
+	if len(resolved) != 13 {
+		t.Fatalf("TestResolve: expected 13 records, got %d", len(resolved))
+	}
+	tt := []struct {
+		index int
+		name  string
+		unit  string
+		time  float64
+		value float64
+	}{
+		{0, "urn:dev:ow:10e2073a01080063:", "%RH", 1.320067464e+09, 20},
+		{1, "urn:dev:ow:10e2073a01080063:", "lon", 1.320067464e+09, 24.30621},
+		{3, "urn:dev:ow:10e2073a01080063:", "%RH", 1.320067524e+09, 20.3},
+		{9, "urn:dev:ow:10e2073a01080063:", "%EL", 1.320067614e+09, 98},
+	}
+	for _, tc := range tt {
+		r := resolved[tc.index]
+		if r.Name != tc.name || r.Unit != tc.unit || r.Time != tc.time || r.Value == nil || *r.Value != tc.value {
+			t.Errorf("TestResolve: record %d: expected %s %s %v %v, got %s %s %v %s", tc.index, tc.name, tc.unit, tc.time, tc.value, r.Name, r.Unit, r.Time, r.ValueString())
+		}
+		if r.BaseName != "" || r.BaseUnit != "" || r.BaseTime != 0 {
+			t.Errorf("TestResolve: record %d: expected base fields to be removed, got %+v", tc.index, r)
+		}
+	}
+}
+
+func TestResolveBaseValueAndRelativeTime(t *testing.T) {
+	bv := 100.0
+	v := 1.5
+	p := Pack{
+		{BaseName: "dev/", BaseValue: &bv, Name: "temp", Value: &v, Time: -10},
+	}
+	now := time.Unix(1700000000, 0)
+	resolved, err := p.Resolve(now)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if *resolved[0].Value != 101.5 {
+		t.Errorf("expected value 101.5, got %v", *resolved[0].Value)
+	}
+	if resolved[0].Time != 1699999990 {
+		t.Errorf("expected time 1699999990, got %v", resolved[0].Time)
+	}
+}
+
+func TestResolveErrors(t *testing.T) {
+	v := 1.0
+	s := "on"
+	tt := []struct {
+		name string
+		pack Pack
+	}{
+		{"missing name", Pack{{Value: &v}}},
+		{"invalid name", Pack{{Name: "-bad", Value: &v}}},
+		{"no value", Pack{{Name: "a"}}},
+		{"two values", Pack{{Name: "a", Value: &v, StringValue: &s}}},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			if _, err := tc.pack.Resolve(time.Now()); err == nil {
+				t.Errorf("expected an error")
+			}
+		})
+	}
+}
+
+func TestConvertRoundTrip(t *testing.T) {
+	v := 23.1
+	s := "open"
+	b := true
+	p := Pack{
+		{BaseName: "urn:dev:mac:0024befffe804ff1:", BaseTime: 1.276020076e+09, BaseUnit: "Cel", Name: "temp", Value: &v},
+		{Name: "door", StringValue: &s, Time: 1},
+		{Name: "alarm", BoolValue: &b},
+		{Name: "blob", DataValue: []byte{0xde, 0xad, 0xbe, 0xef}},
+	}
+	js, err := EncodeJSON(p)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !strings.Contains(string(js), `"vd":"3q2-7w"`) {
+		t.Errorf("expected data value to be base64url encoded, got %s", js)
+	}
+	cb, err := Convert(js, ContentFormatJSON, ContentFormatCBOR)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	// first record is a map starting with the bn label (-2 encodes as 0x21)
+	if cb[0] != 0x84 || !bytes.Contains(cb, []byte{0x21, 0x78}) {
+		t.Errorf("expected CBOR array with integer labels, got %x", cb)
+	}
+	back, err := Convert(cb, ContentFormatCBOR, ContentFormatJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	decoded, err := Decode(back, ContentFormatSensmlJSON)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	if !reflect.DeepEqual(p, decoded) {
+		t.Errorf("expected %+v, got %+v", p, decoded)
+	}
+}
+
+func TestWriteTable(t *testing.T) {
+	v := 21.5
+	p := Pack{{Name: "dev/temp", Unit: "Cel", Value: &v, Time: 1700000000}}
+	buf := &bytes.Buffer{}
+	if err := WriteTable(buf, p); err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	expected := "NAME      TIME                  VALUE  UNIT\ndev/temp  2023-11-14T22:13:20Z  21.5   Cel\n"
+	if buf.String() != expected {
+		t.Errorf("expected\n%q\ngot\n%q", expected, buf.String())
+	}
+}
@@ -0,0 +1,25 @@
+package senml
+
+import (
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+)
+
+// WriteTable prints the records as an aligned table of name, time, value and unit.
+// The pack is expected to be resolved.
+func WriteTable(w io.Writer, p Pack) error {
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "NAME\tTIME\tVALUE\tUNIT"); err != nil {
+		return err
+	}
+	for i := range p {
+		r := &p[i]
+		_, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.Name, r.Timestamp().Format(time.RFC3339Nano), r.ValueString(), r.Unit)
+		if err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,233 @@
+// Package gocoap provides functionality for interacting with CoAP servers.
+package gocoap
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/message/pool"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
+	"github.com/plgd-dev/go-coap/v3/options"
+	coapclient "github.com/plgd-dev/go-coap/v3/udp/client"
+)
+
+// ProgressFunc is called while a transfer is running with the number of bytes
+// transferred so far and the total size, or -1 if the total is unknown.
+type ProgressFunc func(done, total int64)
+
+// Upload sends payload with a POST or PUT request using block-wise transfer.
+// The payload is read block by block as it is sent, so it is never held in memory as a whole.
+// Like Download, each block has its own deadline, so large payloads are not bound by the client timeout.
+// The returned Response is the answer to the last block and its RTT is the duration of the whole transfer.
+// progress may be nil.
+func (c *Client) Upload(method codes.Code, url string, contentId message.MediaType, payload io.ReadSeeker, progress ProgressFunc) (*Response, error) {
+	if method != codes.POST && method != codes.PUT {
+		return nil, fmt.Errorf("unsupported upload method: %v", method)
+	}
+	if payload == nil {
+		return c.Request(method, url, contentId, nil)
+	}
+	total, err := payload.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read payload: %v", err)
+	}
+	if _, err := payload.Seek(0, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("failed to read payload: %v", err)
+	}
+	uri, err := ParseURI(url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks are sent one at a time below, so the automatic fragmentation is switched off
+	conn, err := c.dial(uri, options.WithBlockwise(false, c.blockSize, c.timeout))
+	if err != nil {
+		return nil, err
+	}
+	defer connClose(conn)
+
+	// the blocks are sent with the same token, servers reassemble the payload by token
+	token, err := message.GetToken()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create token: %v", err)
+	}
+	start := time.Now()
+	szx := c.blockSize
+	buf := make([]byte, szx.Size())
+	var offset, num int64
+	for {
+		n, err := io.ReadFull(payload, buf[:szx.Size()])
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return nil, fmt.Errorf("failed to read payload: %v", err)
+		}
+		more := offset+int64(n) < total
+		msg, err := c.sendBlock(conn, uri, token, method, contentId, buf[:n], szx, num, more, total)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := readResponse(msg)
+		if err != nil {
+			return nil, err
+		}
+		resp.Peer = conn.RemoteAddr().String()
+		resp.RTT = resp.Received.Sub(start)
+		if !resp.IsSuccess() {
+			return resp, nil
+		}
+		if more && resp.Code != codes.Continue {
+			// any other answer ends the exchange and the rest of the payload would be lost
+			return nil, fmt.Errorf("server answered the block at offset %d with %v before the upload was complete", offset, resp.Code)
+		}
+		offset += int64(n)
+		if progress != nil {
+			progress(offset, total)
+		}
+		if !more {
+			return resp, nil
+		}
+		if block, err := msg.GetOptionUint32(message.Block1); err == nil {
+			respSzx, _, _, err := blockwise.DecodeBlockOption(block)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Block1 option: %v", err)
+			}
+			// the server may ask for smaller blocks than sent
+			if respSzx < szx {
+				szx = respSzx
+			}
+		}
+		num = offset / szx.Size()
+	}
+}
+
+// Download performs a GET request and streams the response payload into w one block at a time.
+// The returned Response has no Body; its Code and ContentFormat describe the transfer
+// and its RTT is the duration of the whole transfer.
+// If the server answers with an error code, nothing is written and the payload is returned in the Body.
+// progress may be nil.
+func (c *Client) Download(url string, w io.Writer, progress ProgressFunc) (*Response, error) {
+	uri, err := ParseURI(url)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks are requested one at a time below, so the automatic reassembly is switched off
+	conn, err := c.dial(uri, options.WithBlockwise(false, c.blockSize, c.timeout))
+	if err != nil {
+		return nil, err
+	}
+	defer connClose(conn)
+
+	start := time.Now()
+	szx := c.blockSize
+	total := int64(-1)
+	var offset, num int64
+	for {
+		msg, err := c.getBlock(conn, uri, szx, num)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := readResponse(msg)
+		if err != nil {
+			return nil, err
+		}
+		resp.Peer = conn.RemoteAddr().String()
+		resp.RTT = resp.Received.Sub(start)
+		if !resp.IsSuccess() {
+			return resp, nil
+		}
+		if size, err := msg.GetOptionUint32(message.Size2); err == nil {
+			total = int64(size)
+		}
+
+		more := false
+		if block, err := msg.GetOptionUint32(message.Block2); err == nil {
+			var respSzx blockwise.SZX
+			var respNum int64
+			respSzx, respNum, more, err = blockwise.DecodeBlockOption(block)
+			if err != nil {
+				return nil, fmt.Errorf("invalid Block2 option: %v", err)
+			}
+			if respNum*respSzx.Size() != offset {
+				return nil, fmt.Errorf("unexpected block %d of size %d at offset %d", respNum, respSzx.Size(), offset)
+			}
+			// the server may choose a smaller block size than requested
+			szx = respSzx
+		}
+
+		if _, err := w.Write(resp.Body); err != nil {
+			return nil, fmt.Errorf("failed to write response body: %v", err)
+		}
+		offset += int64(len(resp.Body))
+		if progress != nil {
+			progress(offset, total)
+		}
+		if !more {
+			resp.Body = nil
+			return resp, nil
+		}
+		num = offset / szx.Size()
+	}
+}
+
+// getBlock requests a single block of a resource.
+func (c *Client) getBlock(conn *coapclient.Conn, uri *URI, szx blockwise.SZX, num int64) (*pool.Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	req, err := conn.NewGetRequest(ctx, "", c.requestOptions(uri)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	defer conn.ReleaseMessage(req)
+	block, err := blockwise.EncodeBlockOption(szx, num, false)
+	if err != nil {
+		return nil, err
+	}
+	req.SetOptionUint32(message.Block2, block)
+	if num == 0 {
+		// ask the server for the total size
+		req.SetOptionUint32(message.Size2, 0)
+	}
+	resp, err := conn.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	return resp, nil
+}
+
+// sendBlock sends a single block of a POST or PUT payload. The first block carries the total size.
+func (c *Client) sendBlock(conn *coapclient.Conn, uri *URI, token message.Token, method codes.Code, contentId message.MediaType, data []byte, szx blockwise.SZX, num int64, more bool, total int64) (*pool.Message, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	var req *pool.Message
+	var err error
+	if method == codes.POST {
+		req, err = conn.NewPostRequest(ctx, "", contentId, bytes.NewReader(data), c.requestOptions(uri)...)
+	} else {
+		req, err = conn.NewPutRequest(ctx, "", contentId, bytes.NewReader(data), c.requestOptions(uri)...)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %v", err)
+	}
+	defer conn.ReleaseMessage(req)
+	req.SetToken(token)
+	block, err := blockwise.EncodeBlockOption(szx, num, more)
+	if err != nil {
+		return nil, err
+	}
+	req.SetOptionUint32(message.Block1, block)
+	if num == 0 {
+		req.SetOptionUint32(message.Size1, uint32(total))
+	}
+	resp, err := conn.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request: %v", err)
+	}
+	return resp, nil
+}
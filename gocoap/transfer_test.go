@@ -0,0 +1,179 @@
+package gocoap
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/larryr/tools/gocoap/coaptest"
+	"github.com/plgd-dev/go-coap/v3/message"
+	"github.com/plgd-dev/go-coap/v3/message/codes"
+	"github.com/plgd-dev/go-coap/v3/mux"
+	coapnet "github.com/plgd-dev/go-coap/v3/net"
+	"github.com/plgd-dev/go-coap/v3/net/blockwise"
+	"github.com/plgd-dev/go-coap/v3/options"
+	coapudp "github.com/plgd-dev/go-coap/v3/udp"
+)
+
+// startTransferServer serves firmware on /fw and stores what is PUT to /fw.
+func startTransferServer(t *testing.T, firmware []byte) (string, func() []byte) {
+	var mu sync.Mutex
+	var uploaded []byte
+	router := mux.NewRouter()
+	err := router.Handle("/fw", mux.HandlerFunc(func(w mux.ResponseWriter, r *mux.Message) {
+		switch r.Code() {
+		case codes.GET:
+			_ = w.SetResponse(codes.Content, message.AppOctets, bytes.NewReader(firmware))
+		case codes.PUT:
+			body, _ := io.ReadAll(r.Body())
+			mu.Lock()
+			uploaded = body
+			mu.Unlock()
+			_ = w.SetResponse(codes.Changed, message.TextPlain, nil)
+		}
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := coapnet.NewListenUDP("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := coapudp.NewServer(options.WithMux(router))
+	go func() { _ = s.Serve(l) }()
+	t.Cleanup(func() {
+		s.Stop()
+		_ = l.Close()
+	})
+	return "coap://" + l.LocalAddr().String() + "/fw", func() []byte {
+		mu.Lock()
+		defer mu.Unlock()
+		return uploaded
+	}
+}
+
+func firmwareImage(size int) []byte {
+	data := make([]byte, size)
+	for i := range data {
+		data[i] = byte(i * 7)
+	}
+	return data
+}
+
+func TestDownload(t *testing.T) {
+	firmware := firmwareImage(10000)
+	url, _ := startTransferServer(t, firmware)
+	client := NewClient(2*time.Second, WithBlockSize(blockwise.SZX256))
+
+	var calls int
+	var lastDone int64
+	buf := &bytes.Buffer{}
+	resp, err := client.Download(url, buf, func(done, total int64) {
+		calls++
+		lastDone = done
+	})
+	if err != nil {
+		t.Fatalf("TestDownload: expected no error, got %s", err)
+	}
+	if resp.Code != codes.Content {
+		t.Errorf("TestDownload: expected code %v, got %v", codes.Content, resp.Code)
+	}
+	if !bytes.Equal(buf.Bytes(), firmware) {
+		t.Errorf("TestDownload: downloaded %d bytes that do not match the %d byte image", buf.Len(), len(firmware))
+	}
+	if calls != 40 || lastDone != int64(len(firmware)) {
+		t.Errorf("TestDownload: expected 40 progress calls ending at %d, got %d ending at %d", len(firmware), calls, lastDone)
+	}
+}
+
+func TestUpload(t *testing.T) {
+	firmware := firmwareImage(5000)
+	url, uploaded := startTransferServer(t, nil)
+	client := NewClient(2*time.Second, WithBlockSize(blockwise.SZX512))
+
+	var lastDone, lastTotal int64
+	resp, err := client.Upload(codes.PUT, url, message.AppOctets, bytes.NewReader(firmware), func(done, total int64) {
+		lastDone, lastTotal = done, total
+	})
+	if err != nil {
+		t.Fatalf("TestUpload: expected no error, got %s", err)
+	}
+	if resp.Code != codes.Changed {
+		t.Errorf("TestUpload: expected code %v, got %v", codes.Changed, resp.Code)
+	}
+	if !bytes.Equal(uploaded(), firmware) {
+		t.Errorf("TestUpload: server received %d bytes that do not match the %d byte image", len(uploaded()), len(firmware))
+	}
+	if lastDone != 5000 || lastTotal != 5000 {
+		t.Errorf("TestUpload: expected progress 5000/5000, got %d/%d", lastDone, lastTotal)
+	}
+}
+
+func TestUploadPerBlockDeadline(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	uploaded := make(chan []byte, 1)
+	srv.HandleFunc("/fw", func(r *coaptest.Request) *coaptest.Response {
+		uploaded <- r.Payload
+		return &coaptest.Response{Code: codes.Changed}
+	})
+	// 40 blocks of 64 bytes answered after 20ms each take twice the client timeout
+	srv.SetDelay(20 * time.Millisecond)
+	client := NewClient(400*time.Millisecond, WithBlockSize(blockwise.SZX64))
+	firmware := firmwareImage(40 * 64)
+
+	start := time.Now()
+	resp, err := client.Upload(codes.PUT, srv.URL+"/fw", message.AppOctets, bytes.NewReader(firmware), nil)
+	if err != nil {
+		t.Fatalf("TestUploadPerBlockDeadline: expected no error, got %s", err)
+	}
+	if elapsed := time.Since(start); elapsed < 400*time.Millisecond {
+		t.Errorf("TestUploadPerBlockDeadline: expected the upload to take longer than the client timeout, took %v", elapsed)
+	}
+	if resp.Code != codes.Changed {
+		t.Errorf("TestUploadPerBlockDeadline: expected code %v, got %v", codes.Changed, resp.Code)
+	}
+	select {
+	case payload := <-uploaded:
+		if !bytes.Equal(payload, firmware) {
+			t.Errorf("TestUploadPerBlockDeadline: server received %d bytes that do not match the %d byte image", len(payload), len(firmware))
+		}
+	default:
+		t.Errorf("TestUploadPerBlockDeadline: expected the handler to receive the upload")
+	}
+}
+
+func TestUploadEndedEarly(t *testing.T) {
+	srv := coaptest.NewServer()
+	defer srv.Close()
+	srv.SetUploadBlocks(true)
+	srv.Handle("/fw", coaptest.Response{Code: codes.Changed})
+	client := NewClient(2*time.Second, WithBlockSize(blockwise.SZX64))
+
+	var calls int
+	_, err := client.Upload(codes.PUT, srv.URL+"/fw", message.AppOctets, bytes.NewReader(firmwareImage(4*64)), func(done, total int64) {
+		calls++
+	})
+	if err == nil || !strings.Contains(err.Error(), "offset 0") || !strings.Contains(err.Error(), codes.Changed.String()) {
+		t.Fatalf("TestUploadEndedEarly: expected an error naming offset 0 and %v, got %v", codes.Changed, err)
+	}
+	if calls != 0 {
+		t.Errorf("TestUploadEndedEarly: expected no progress, got %d calls", calls)
+	}
+	if requests := srv.Requests(); len(requests) != 1 {
+		t.Errorf("TestUploadEndedEarly: expected 1 block to be sent, got %d", len(requests))
+	}
+}
+
+func TestValidateBlockSize(t *testing.T) {
+	szx, err := ValidateBlockSize(64)
+	if err != nil || szx != blockwise.SZX64 {
+		t.Errorf("TestValidateBlockSize: expected SZX64, got %v %v", szx, err)
+	}
+	if _, err := ValidateBlockSize(100); err == nil {
+		t.Errorf("TestValidateBlockSize: expected an error for 100")
+	}
+}
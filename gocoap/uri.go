@@ -0,0 +1,234 @@
+// Package gocoap provides functionality for interacting with CoAP servers.
+package gocoap
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+)
+
+// Default ports for the coap and coaps schemes (RFC 7252 section 6).
+const (
+	DefaultPort       = 5683
+	DefaultSecurePort = 5684
+)
+
+// URI is a CoAP URI decomposed as described in RFC 7252 section 6.4.
+type URI struct {
+	// Scheme is "coap" or "coaps".
+	Scheme string
+	// Host is a host name or an IP address without brackets. An IPv6 address may carry a %zone.
+	Host string
+	// Port is the explicit port or the default port of the scheme.
+	Port int
+	// Path holds the percent-decoded Uri-Path segments. Segments may be empty.
+	Path []string
+	// Query holds the percent-decoded Uri-Query arguments.
+	Query []string
+}
+
+// ParseURI parses a coap:// or coaps:// URI.
+// IPv6 literals may carry a zone either escaped as in RFC 6874 (fe80::1%25eth0) or
+// unescaped (fe80::1%eth0), since the latter is what people type.
+func ParseURI(rawURL string) (*URI, error) {
+	u := &URI{}
+	scheme, rest, ok := strings.Cut(rawURL, "://")
+	if !ok {
+		return nil, fmt.Errorf("invalid CoAP URL (must start with coap:// or coaps://): %s", rawURL)
+	}
+	u.Scheme = strings.ToLower(scheme)
+	switch u.Scheme {
+	case "coap":
+		u.Port = DefaultPort
+	case "coaps":
+		u.Port = DefaultSecurePort
+	default:
+		return nil, fmt.Errorf("invalid CoAP URL (must start with coap:// or coaps://): %s", rawURL)
+	}
+	if strings.Contains(rest, "#") {
+		return nil, fmt.Errorf("invalid CoAP URL (fragments are not allowed): %s", rawURL)
+	}
+
+	authority := rest
+	pathAndQuery := ""
+	if i := strings.IndexAny(rest, "/?"); i >= 0 {
+		authority, pathAndQuery = rest[:i], rest[i:]
+	}
+	if err := u.parseAuthority(authority); err != nil {
+		return nil, fmt.Errorf("invalid CoAP URL %s: %v", rawURL, err)
+	}
+
+	rawPath, rawQuery, hasQuery := strings.Cut(pathAndQuery, "?")
+	if rawPath != "" && rawPath != "/" {
+		for _, segment := range strings.Split(rawPath[1:], "/") {
+			s, err := url.PathUnescape(segment)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CoAP URL %s: %v", rawURL, err)
+			}
+			u.Path = append(u.Path, s)
+		}
+	}
+	if hasQuery {
+		for _, arg := range strings.Split(rawQuery, "&") {
+			s, err := url.PathUnescape(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CoAP URL %s: %v", rawURL, err)
+			}
+			u.Query = append(u.Query, s)
+		}
+	}
+	return u, nil
+}
+
+// parseAuthority fills in the host and port from the authority component of the URI.
+func (u *URI) parseAuthority(authority string) error {
+	if authority == "" {
+		return fmt.Errorf("missing host")
+	}
+	if strings.Contains(authority, "@") {
+		return fmt.Errorf("user information is not allowed")
+	}
+
+	host, port := authority, ""
+	if strings.HasPrefix(authority, "[") {
+		end := strings.Index(authority, "]")
+		if end < 0 {
+			return fmt.Errorf("missing ']' in host")
+		}
+		host = authority[1:end]
+		switch rest := authority[end+1:]; {
+		case rest == "":
+		case rest[0] == ':':
+			port = rest[1:]
+		default:
+			return fmt.Errorf("unexpected %q after host", rest)
+		}
+		// RFC 6874 separates the zone with an encoded "%25", a plain "%" is accepted as well
+		addr, zone, hasZone := strings.Cut(host, "%25")
+		if !hasZone {
+			addr, zone, hasZone = strings.Cut(host, "%")
+		}
+		if ip := net.ParseIP(addr); ip == nil || ip.To4() != nil {
+			return fmt.Errorf("invalid IPv6 address %q", addr)
+		}
+		if hasZone {
+			if zone == "" {
+				return fmt.Errorf("empty IPv6 zone")
+			}
+			z, err := url.PathUnescape(zone)
+			if err != nil {
+				return err
+			}
+			host = addr + "%" + z
+		}
+	} else {
+		if i := strings.LastIndex(authority, ":"); i >= 0 {
+			host, port = authority[:i], authority[i+1:]
+		}
+		if host == "" {
+			return fmt.Errorf("missing host")
+		}
+		h, err := url.PathUnescape(host)
+		if err != nil {
+			return err
+		}
+		host = strings.ToLower(h)
+	}
+	u.Host = host
+
+	if port != "" {
+		p, err := strconv.Atoi(port)
+		if err != nil || p < 1 || p > 65535 {
+			return fmt.Errorf("invalid port %q", port)
+		}
+		u.Port = p
+	}
+	return nil
+}
+
+// Address returns the host and port in a form suitable for net.Dial, e.g. [fe80::1%eth0]:5683.
+func (u *URI) Address() string {
+	return net.JoinHostPort(u.Host, strconv.Itoa(u.Port))
+}
+
+// PathString returns the path for display, e.g. /sensors/temp.
+func (u *URI) PathString() string {
+	return "/" + strings.Join(u.Path, "/")
+}
+
+// Options returns the Uri-Path and Uri-Query options of a request for this URI.
+func (u *URI) Options() message.Options {
+	opts := make(message.Options, 0, len(u.Path)+len(u.Query))
+	for _, segment := range u.Path {
+		opts = append(opts, message.Option{ID: message.URIPath, Value: []byte(segment)})
+	}
+	for _, arg := range u.Query {
+		opts = append(opts, message.Option{ID: message.URIQuery, Value: []byte(arg)})
+	}
+	return opts
+}
+
+// String reassembles the URI. The default port is omitted and IPv6 zones are escaped as in RFC 6874.
+func (u *URI) String() string {
+	sb := &strings.Builder{}
+	sb.WriteString(u.Scheme)
+	sb.WriteString("://")
+	if strings.Contains(u.Host, ":") {
+		addr, zone, hasZone := strings.Cut(u.Host, "%")
+		sb.WriteString("[" + addr)
+		if hasZone {
+			sb.WriteString("%25" + escape(zone, isUnreserved))
+		}
+		sb.WriteString("]")
+	} else {
+		sb.WriteString(escape(u.Host, isUnreserved))
+	}
+	if (u.Scheme == "coap" && u.Port != DefaultPort) || (u.Scheme == "coaps" && u.Port != DefaultSecurePort) {
+		sb.WriteString(":" + strconv.Itoa(u.Port))
+	}
+	for _, segment := range u.Path {
+		sb.WriteString("/" + escape(segment, isPathChar))
+	}
+	for i, arg := range u.Query {
+		if i == 0 {
+			sb.WriteString("?")
+		} else {
+			sb.WriteString("&")
+		}
+		sb.WriteString(escape(arg, isQueryChar))
+	}
+	return sb.String()
+}
+
+// escape percent-encodes every byte of s that is not allowed.
+func escape(s string, allowed func(c byte) bool) string {
+	sb := &strings.Builder{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if allowed(c) {
+			sb.WriteByte(c)
+		} else {
+			fmt.Fprintf(sb, "%%%02X", c)
+		}
+	}
+	return sb.String()
+}
+
+// isUnreserved reports the unreserved characters of RFC 3986.
+func isUnreserved(c byte) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || strings.IndexByte("-._~", c) >= 0
+}
+
+// isPathChar reports the characters allowed in a path segment (pchar of RFC 3986).
+func isPathChar(c byte) bool {
+	return isUnreserved(c) || strings.IndexByte("!$&'()*+,;=:@", c) >= 0
+}
+
+// isQueryChar reports the characters allowed in a query argument. '&' separates arguments.
+func isQueryChar(c byte) bool {
+	return c != '&' && (isPathChar(c) || c == '/' || c == '?')
+}
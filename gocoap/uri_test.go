@@ -0,0 +1,109 @@
+package gocoap
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/plgd-dev/go-coap/v3/message"
+)
+
+func TestParseURI(t *testing.T) {
+	tests := []struct {
+		raw     string
+		address string
+		path    []string
+		query   []string
+		str     string
+	}{
+		{"coap://device", "device:5683", nil, nil, "coap://device"},
+		{"coap://device/", "device:5683", nil, nil, "coap://device"},
+		{"coaps://device/x", "device:5684", []string{"x"}, nil, "coaps://device/x"},
+		{"COAP://Device:1234/x", "device:1234", []string{"x"}, nil, "coap://device:1234/x"},
+		{"coap://device:5683/x", "device:5683", []string{"x"}, nil, "coap://device/x"},
+		{"coap://device:/x", "device:5683", []string{"x"}, nil, "coap://device/x"},
+		{"coap://192.0.2.1/a/b", "192.0.2.1:5683", []string{"a", "b"}, nil, "coap://192.0.2.1/a/b"},
+		{"coap://[2001:db8::1]/x", "[2001:db8::1]:5683", []string{"x"}, nil, "coap://[2001:db8::1]/x"},
+		{"coap://[2001:db8::1]:61616/x", "[2001:db8::1]:61616", []string{"x"}, nil, "coap://[2001:db8::1]:61616/x"},
+		{"coap://[fe80::1%eth0]/x", "[fe80::1%eth0]:5683", []string{"x"}, nil, "coap://[fe80::1%25eth0]/x"},
+		{"coap://[fe80::1%25eth0]:5700/x", "[fe80::1%eth0]:5700", []string{"x"}, nil, "coap://[fe80::1%25eth0]:5700/x"},
+		{"coap://[fe80::1%25251]/x", "[fe80::1%251]:5683", []string{"x"}, nil, "coap://[fe80::1%25251]/x"},
+		{"coap://[fe80::1%251]/x", "[fe80::1%1]:5683", []string{"x"}, nil, "coap://[fe80::1%251]/x"},
+		{"coap://h/a%2Fb/c", "h:5683", []string{"a/b", "c"}, nil, "coap://h/a%2Fb/c"},
+		{"coap://h/a%20b", "h:5683", []string{"a b"}, nil, "coap://h/a%20b"},
+		{"coap://h/a//b/", "h:5683", []string{"a", "", "b", ""}, nil, "coap://h/a//b/"},
+		{"coap://h/%7Euser", "h:5683", []string{"~user"}, nil, "coap://h/~user"},
+		{"coap://h/x?a=1&b=2", "h:5683", []string{"x"}, []string{"a=1", "b=2"}, "coap://h/x?a=1&b=2"},
+		{"coap://h?rt=temp", "h:5683", nil, []string{"rt=temp"}, "coap://h?rt=temp"},
+		{"coap://h/x?a%26b=1+2", "h:5683", []string{"x"}, []string{"a&b=1+2"}, "coap://h/x?a%26b=1+2"},
+		{"coap://h/x?", "h:5683", []string{"x"}, []string{""}, "coap://h/x?"},
+	}
+	for _, tt := range tests {
+		u, err := ParseURI(tt.raw)
+		if err != nil {
+			t.Errorf("TestParseURI: %s: expected no error, got %s", tt.raw, err)
+			continue
+		}
+		if u.Address() != tt.address {
+			t.Errorf("TestParseURI: %s: expected address %s, got %s", tt.raw, tt.address, u.Address())
+		}
+		if !reflect.DeepEqual(u.Path, tt.path) {
+			t.Errorf("TestParseURI: %s: expected path %q, got %q", tt.raw, tt.path, u.Path)
+		}
+		if !reflect.DeepEqual(u.Query, tt.query) {
+			t.Errorf("TestParseURI: %s: expected query %q, got %q", tt.raw, tt.query, u.Query)
+		}
+		if u.String() != tt.str {
+			t.Errorf("TestParseURI: %s: expected string %s, got %s", tt.raw, tt.str, u.String())
+		}
+
+		// the reassembled URI must parse to the same thing
+		again, err := ParseURI(u.String())
+		if err != nil {
+			t.Errorf("TestParseURI: %s: expected the round trip to parse, got %s", tt.raw, err)
+			continue
+		}
+		if !reflect.DeepEqual(again, u) {
+			t.Errorf("TestParseURI: %s: round trip changed %+v to %+v", tt.raw, u, again)
+		}
+	}
+}
+
+func TestParseURIErrors(t *testing.T) {
+	tests := []string{
+		"http://h/x",
+		"device/x",
+		"coap:///x",
+		"coap://h/x#frag",
+		"coap://user@h/x",
+		"coap://h:0/x",
+		"coap://h:70000/x",
+		"coap://h:port/x",
+		"coap://[2001:db8::1/x",
+		"coap://[192.0.2.1]/x",
+		"coap://[fe80::1%]/x",
+		"coap://[fe80::1%25]/x",
+		"coap://[2001:db8::1]x/y",
+		"coap://h/%zz",
+		"coap://h/x?%zz",
+	}
+	for _, raw := range tests {
+		if u, err := ParseURI(raw); err == nil {
+			t.Errorf("TestParseURIErrors: %s: expected an error, got %+v", raw, u)
+		}
+	}
+}
+
+func TestURIOptions(t *testing.T) {
+	u, err := ParseURI("coap://h/a%2Fb/c?x=1")
+	if err != nil {
+		t.Fatalf("TestURIOptions: expected no error, got %s", err)
+	}
+	expected := message.Options{
+		{ID: message.URIPath, Value: []byte("a/b")},
+		{ID: message.URIPath, Value: []byte("c")},
+		{ID: message.URIQuery, Value: []byte("x=1")},
+	}
+	if !reflect.DeepEqual(u.Options(), expected) {
+		t.Errorf("TestURIOptions: expected %v, got %v", expected, u.Options())
+	}
+}
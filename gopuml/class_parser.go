@@ -17,16 +17,21 @@ package gopuml
 import (
 	"fmt"
 	"go/ast"
+	"go/build"
 	"go/parser"
 	"go/token"
+	"go/types"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
+	"runtime"
 	"sort"
 	"strings"
 	"unicode"
 
 	"github.com/spf13/afero"
+	"golang.org/x/tools/go/packages"
 )
 
 //LineStringBuilder extends the strings.Builder and adds functionality to build a string with tabs and
@@ -56,6 +61,15 @@ type ClassDiagramOptions struct {
 	IgnoredDirectories []string
 	RenderingOptions   map[RenderingOption]interface{}
 	Recursive          bool
+	// BuildTags, GOOS and GOARCH select the files like go build does. GOOS and GOARCH default to
+	// the platform gopuml runs on. Like go build, cgo files are skipped for other platforms unless
+	// CGO_ENABLED=1 is set.
+	BuildTags []string
+	GOOS      string
+	GOARCH    string
+	// TypeChecked loads the directories with go/packages and resolves implementations, aliases and
+	// imported package names with go/types. The directories must be part of a module and type check.
+	TypeChecked bool
 }
 
 //RenderingOptions will allow the class parser to optionally enebale or disable the things to render.
@@ -71,6 +85,22 @@ type RenderingOptions struct {
 	Aliases                 bool
 	ConnectionLabels        bool
 	AggregatePrivateMembers bool
+	NamespacePrefix         string
+	ExternalPackages        bool
+	PackageCycles           bool
+	Focus                   string
+	FocusDepth              int
+	Include                 []string
+	Exclude                 []string
+	Docs                    string
+	MethodDocs              bool
+	Tags                    bool
+	Enums                   bool
+	Utilities               bool
+	Theme                   string
+	Direction               string
+	Grouping                string
+	HiddenLinks             []string
 }
 
 const aliasComplexNameComment = "'This class was created so that we can correctly have an alias pointing to this name. Since it contains dots that can break namespaces"
@@ -108,6 +138,54 @@ const AggregatePrivateMembers = 9
 //RenderStyle is an option to specify an include url to pick up custom skinparams
 const RenderStyle = 10
 
+//RenderNamespacePrefix is an import path prefix that is removed from the package namespaces, e.g. the module path
+const RenderNamespacePrefix = 11
+
+//RenderExternalPackages is to be used in the SetRenderingOptions argument as the key to the map, when value is true, the package diagram will render the imported packages that were not parsed
+const RenderExternalPackages = 12
+
+//RenderPackageCycles is to be used in the SetRenderingOptions argument as the key to the map, when value is true, the package diagram will highlight import cycles
+const RenderPackageCycles = 13
+
+//RenderFocus is a type, as importpath.Type or package.Type, that the diagram is focused on: only the types within RenderFocusDepth relations of it are rendered
+const RenderFocus = 14
+
+//RenderFocusDepth is the number of relations, compositions, implementations, aggregations or aliases, a type can be away from the RenderFocus type to be rendered
+const RenderFocusDepth = 15
+
+//RenderInclude is a list of regular expressions. When set, only the types whose package qualified name, e.g. github.com/larryr/tools/gopuml.ClassParser, matches one of them are rendered
+const RenderInclude = 16
+
+//RenderExclude is a list of regular expressions of package qualified type names that are not rendered
+const RenderExclude = 17
+
+//RenderDocs renders the doc comments of the types as notes: DocsSummary for their first sentence, DocsFull for the whole text, or an empty string for none
+const RenderDocs = 18
+
+//RenderMethodDocs is to be used in the SetRenderingOptions argument as the key to the map, when value is true, the methods link to their documentation with the first sentence of their doc comment as the tooltip, shown in SVG output
+const RenderMethodDocs = 19
+
+//RenderTags is to be used in the SetRenderingOptions argument as the key to the map, when value is true, the struct tags are rendered after their fields
+const RenderTags = 20
+
+//RenderEnums is to be used in the SetRenderingOptions argument as the key to the map, when value is true, the named types with typed constants are rendered as enums of their constants and values
+const RenderEnums = 21
+
+//RenderUtilities is to be used in the SetRenderingOptions argument as the key to the map, when value is true, the package level functions, variables and constants of each package are rendered in a utility class
+const RenderUtilities = 22
+
+//RenderTheme is the name of a theme bundled with gopuml, see Themes, whose skinparams are inlined in the diagrams
+const RenderTheme = 23
+
+//RenderDirection is the layout direction of the class and package diagrams, DirectionLeftToRight or DirectionTopToBottom
+const RenderDirection = 24
+
+//RenderGrouping is how the types of a package are grouped: in a namespace, the default, or in a package drawn as a package, folder, frame, rectangle, cloud, node or database
+const RenderGrouping = 25
+
+//RenderHiddenLinks is a list of From->To hints between types, given like RenderFocus, that are laid out like connections without being drawn
+const RenderHiddenLinks = 26
+
 //RenderingOption is an alias for an it so it is easier to use it as options in a map (see SetRenderingOptions(map[RenderingOption]bool) error)
 type RenderingOption int
 
@@ -122,30 +200,48 @@ type ClassParser struct {
 	allImports         map[string]string
 	allAliases         map[string]*Alias
 	allRenamedStructs  map[string]map[string]string
+	// packageImports holds the import paths imported by each parsed package
+	packageImports map[string]map[string]struct{}
+	fileSystem     afero.Fs
+	buildContext   *build.Context
+	// typeInfo holds the types of the package being parsed in type checked mode
+	typeInfo *types.Info
+	// typedPackages holds the packages loaded in type checked mode
+	typedPackages []*types.Package
+	// visible holds the types shown by the Render call in progress, nil when all are shown
+	visible map[string]bool
+	// loadedPackages holds the packages loaded in type checked mode, for building their SSA form
+	loadedPackages []*packages.Package
+	// constants holds the typed constants of each package by the qualified name of their type
+	constants map[string]map[string][]*Constant
+	// utilities holds the package level functions, variables and constants of each package
+	utilities map[string]*Struct
+	// creates holds the types the package level functions of each package return
+	creates map[string]map[string]struct{}
+	// diff holds how the types changed since the revision passed to Diff, nil when no diff is rendered
+	diff map[*Struct]*structDiff
+	// usedTypes holds the types of other packages the loaded packages refer to
+	usedTypes map[*types.TypeName]struct{}
 }
 
 //NewClassDiagramWithOptions returns a new classParser with which can Render the class diagram of
 // files in the given directory passed in the ClassDiargamOptions. This will also alow for different types of FileSystems
 // Passed since it is part of the ClassDiagramOptions as well.
 func NewClassDiagramWithOptions(options *ClassDiagramOptions) (*ClassParser, error) {
-	classParser := &ClassParser{
-		renderingOptions: &RenderingOptions{
-			Aggregations:     false,
-			Fields:           true,
-			Methods:          true,
-			Compositions:     true,
-			Implementations:  true,
-			Aliases:          true,
-			ConnectionLabels: false,
-			Title:            "",
-			Notes:            "",
-		},
-		structure:         make(map[string]map[string]*Struct),
-		allInterfaces:     make(map[string]struct{}),
-		allStructs:        make(map[string]struct{}),
-		allImports:        make(map[string]string),
-		allAliases:        make(map[string]*Alias),
-		allRenamedStructs: make(map[string]map[string]string),
+	classParser := newClassParser()
+	classParser.fileSystem = options.FileSystem
+	if classParser.fileSystem == nil {
+		classParser.fileSystem = afero.NewOsFs()
+	}
+	classParser.buildContext = newBuildContext(options, classParser.fileSystem)
+	if options.TypeChecked {
+		if err := classParser.parsePackages(options); err != nil {
+			return nil, err
+		}
+		if err := classParser.SetRenderingOptions(options.RenderingOptions); err != nil {
+			return nil, err
+		}
+		return classParser, nil
 	}
 	ignoreDirectoryMap := map[string]struct{}{}
 	for _, dir := range options.IgnoredDirectories {
@@ -153,7 +249,7 @@ func NewClassDiagramWithOptions(options *ClassDiagramOptions) (*ClassParser, err
 	}
 	for _, directoryPath := range options.Directories {
 		if options.Recursive {
-			err := afero.Walk(options.FileSystem, directoryPath, func(path string, info os.FileInfo, err error) error {
+			err := afero.Walk(classParser.fileSystem, directoryPath, func(path string, info os.FileInfo, err error) error {
 				if err != nil {
 					return err
 				}
@@ -190,10 +286,39 @@ func NewClassDiagramWithOptions(options *ClassDiagramOptions) (*ClassParser, err
 			}
 		}
 	}
-	classParser.SetRenderingOptions(options.RenderingOptions)
+	if err := classParser.SetRenderingOptions(options.RenderingOptions); err != nil {
+		return nil, err
+	}
 	return classParser, nil
 }
 
+//newClassParser returns a ClassParser without types, with the default rendering options
+func newClassParser() *ClassParser {
+	return &ClassParser{
+		renderingOptions: &RenderingOptions{
+			Aggregations:     false,
+			Fields:           true,
+			Methods:          true,
+			Compositions:     true,
+			Implementations:  true,
+			Aliases:          true,
+			ConnectionLabels: false,
+			Title:            "",
+			Notes:            "",
+		},
+		structure:         make(map[string]map[string]*Struct),
+		allInterfaces:     make(map[string]struct{}),
+		allStructs:        make(map[string]struct{}),
+		allImports:        make(map[string]string),
+		allAliases:        make(map[string]*Alias),
+		allRenamedStructs: make(map[string]map[string]string),
+		packageImports:    make(map[string]map[string]struct{}),
+		constants:         make(map[string]map[string][]*Constant),
+		utilities:         make(map[string]*Struct),
+		creates:           make(map[string]map[string]struct{}),
+	}
+}
+
 //NewClassDiagram returns a new classParser with which can Render the class diagram of
 // files in the given directory
 func NewClassDiagram(directoryPaths []string, ignoreDirectories []string, recursive bool) (*ClassParser, error) {
@@ -207,10 +332,10 @@ func NewClassDiagram(directoryPaths []string, ignoreDirectories []string, recurs
 	return NewClassDiagramWithOptions(options)
 }
 
-//parse the given ast.Package into the ClassParser structure
-func (p *ClassParser) parsePackage(node ast.Node) {
+//parse the given ast.Package into the ClassParser structure. Its types are kept under the import path
+func (p *ClassParser) parsePackage(node ast.Node, importPath string) {
 	pack := node.(*ast.Package)
-	p.currentPackageName = pack.Name
+	p.currentPackageName = importPath
 	_, ok := p.structure[p.currentPackageName]
 	if !ok {
 		p.structure[p.currentPackageName] = make(map[string]*Struct)
@@ -227,33 +352,101 @@ func (p *ClassParser) parsePackage(node ast.Node) {
 			for _, d := range f.Imports {
 				p.parseImports(d)
 			}
-			for _, d := range f.Decls {
-				p.parseFileDeclarations(d)
-			}
+			p.parseFile(f)
 		}
 	}
 }
 
+//parseFile parses the declarations of a file of the current package
+func (p *ClassParser) parseFile(f *ast.File) {
+	for _, d := range f.Decls {
+		p.parseFileDeclarations(d)
+	}
+}
+
+//parseImports records the import path of an import under the name it is used with
 func (p *ClassParser) parseImports(impt *ast.ImportSpec) {
+	importPath := strings.Trim(impt.Path.Value, `"`)
+	p.addPackageImport(importPath)
+	name := packageNameOf(importPath)
 	if impt.Name != nil {
-		splitPath := strings.Split(impt.Path.Value, "/")
-		s := strings.TrimRight(splitPath[len(splitPath)-1], `"`)
-		p.allImports[impt.Name.Name] = s
+		name = impt.Name.Name
+	}
+	if name != "_" && name != "." {
+		p.allImports[name] = importPath
 	}
 }
 
+//parseDirectory parses the files of the directory that go build would compile for the selected
+//platform and build tags
 func (p *ClassParser) parseDirectory(directoryPath string) error {
 	fs := token.NewFileSet()
-	result, err := parser.ParseDir(fs, directoryPath, nil, 0)
+	infos, err := afero.ReadDir(p.fileSystem, directoryPath)
 	if err != nil {
 		return err
 	}
+	result := map[string]*ast.Package{}
+	for _, info := range infos {
+		if info.IsDir() || !strings.HasSuffix(info.Name(), ".go") || strings.HasSuffix(info.Name(), "_test.go") {
+			continue
+		}
+		match, err := p.buildContext.MatchFile(directoryPath, info.Name())
+		if err != nil {
+			return err
+		}
+		if !match {
+			continue
+		}
+		path := filepath.Join(directoryPath, info.Name())
+		src, err := afero.ReadFile(p.fileSystem, path)
+		if err != nil {
+			return err
+		}
+		f, err := parser.ParseFile(fs, path, src, parser.ParseComments)
+		if err != nil {
+			return err
+		}
+		pack, ok := result[f.Name.Name]
+		if !ok {
+			pack = &ast.Package{Name: f.Name.Name, Files: map[string]*ast.File{}}
+			result[f.Name.Name] = pack
+		}
+		pack.Files[path] = f
+	}
 	for _, v := range result {
-		p.parsePackage(v)
+		p.parsePackage(v, p.importPath(directoryPath, v.Name))
 	}
 	return nil
 }
 
+//newBuildContext returns the go/build context that selects the files for the platform and build tags
+//of the options. Files are read from the file system of the options.
+func newBuildContext(options *ClassDiagramOptions, fileSystem afero.Fs) *build.Context {
+	ctx := build.Default
+	if options.GOOS != "" {
+		ctx.GOOS = options.GOOS
+	}
+	if options.GOARCH != "" {
+		ctx.GOARCH = options.GOARCH
+	}
+	// go build disables cgo when cross compiling unless it is asked for
+	if ctx.GOOS != runtime.GOOS || ctx.GOARCH != runtime.GOARCH {
+		ctx.CgoEnabled = os.Getenv("CGO_ENABLED") == "1"
+	}
+	ctx.BuildTags = options.BuildTags
+	ctx.OpenFile = func(path string) (io.ReadCloser, error) {
+		return fileSystem.Open(path)
+	}
+	ctx.ReadDir = func(dir string) ([]os.FileInfo, error) {
+		return afero.ReadDir(fileSystem, dir)
+	}
+	ctx.IsDir = func(path string) bool {
+		isDir, err := afero.IsDir(fileSystem, path)
+		return err == nil && isDir
+	}
+	return &ctx
+}
+
 //parse the given declaration looking for classes, interfaces, or member functions
 func (p *ClassParser) parseFileDeclarations(node ast.Decl) {
 	switch decl := node.(type) {
@@ -273,9 +466,10 @@ func (p *ClassParser) handleFuncDecl(decl *ast.FuncDecl) {
 
 		// Only get in when the function is defined for a structure. Global functions are not needed for class diagram
 		theType, _ := getFieldType(decl.Recv.List[0].Type, p.allImports)
-		theType = replacePackageConstant(theType, "")
-		if theType[0] == "*"[0] {
-			theType = theType[1:]
+		// the receiver of a method of a generic type lists its type parameters: *Map[K, V]
+		theType = getGenericType(strings.TrimPrefix(replacePackageConstant(theType, ""), "*"))
+		if theType == "" {
+			return
 		}
 		structure := p.getOrCreateStruct(theType)
 		if structure.Type == "" {
@@ -291,7 +485,9 @@ func (p *ClassParser) handleFuncDecl(decl *ast.FuncDecl) {
 			Tag:     nil,
 			Comment: nil,
 		}, p.allImports)
+		return
 	}
+	p.handleFreeFuncDecl(decl)
 }
 
 func handleGenDecStructType(p *ClassParser, typeName string, c *ast.StructType) {
@@ -309,9 +505,23 @@ func handleGenDecInterfaceType(p *ClassParser, typeName string, c *ast.Interface
 		case *ast.Ident:
 			f, _ := getFieldType(t, p.allImports)
 			st := p.getOrCreateStruct(typeName)
+			if isPrimitive(t) {
+				// interface{ int } is a constraint
+				st.TypeSet = append(st.TypeSet, f)
+				break
+			}
 			f = replacePackageConstant(f, st.PackageName)
 			st.AddToComposition(f)
 			break
+		case *ast.IndexExpr, *ast.IndexListExpr:
+			f, _ := getFieldType(t, p.allImports)
+			st := p.getOrCreateStruct(typeName)
+			st.AddToComposition(getGenericType(replacePackageConstant(f, st.PackageName)))
+		case *ast.UnaryExpr, *ast.BinaryExpr:
+			// the type set of a constraint, e.g. ~int | ~float64
+			f, _ := getFieldType(t, p.allImports)
+			st := p.getOrCreateStruct(typeName)
+			st.TypeSet = append(st.TypeSet, replacePackageConstant(f, ""))
 		}
 	}
 }
@@ -321,38 +531,75 @@ func (p *ClassParser) handleGenDecl(decl *ast.GenDecl) {
 		//This might be a type of General Declaration we do not know how to handle.
 		return
 	}
+	switch decl.Tok {
+	case token.CONST:
+		p.handleConstDecl(decl)
+		return
+	case token.VAR:
+		p.handleVarDecl(decl)
+		return
+	}
 	for _, spec := range decl.Specs {
-		p.processSpec(spec)
+		doc := decl.Doc
+		if len(decl.Specs) > 1 {
+			// the comment of a group of declarations is not the doc of each of them
+			doc = nil
+		}
+		p.processSpec(spec, doc)
 	}
 }
 
-func (p *ClassParser) processSpec(spec ast.Spec) {
+func (p *ClassParser) processSpec(spec ast.Spec, doc *ast.CommentGroup) {
 	var typeName string
 	var alias *Alias
 	declarationType := "alias"
 	switch v := spec.(type) {
 	case *ast.TypeSpec:
 		typeName = v.Name.Name
+		if v.Doc != nil {
+			doc = v.Doc
+		}
 		switch c := v.Type.(type) {
 		case *ast.StructType:
 			declarationType = "class"
+			p.addTypeParams(typeName, v.TypeParams)
 			handleGenDecStructType(p, typeName, c)
 		case *ast.InterfaceType:
 			declarationType = "interface"
+			p.addTypeParams(typeName, v.TypeParams)
 			handleGenDecInterfaceType(p, typeName, c)
 		default:
 			basicType, _ := getFieldType(getBasicType(c), p.allImports)
 
 			aliasType, _ := getFieldType(c, p.allImports)
 			aliasType = replacePackageConstant(aliasType, "")
+			switch c.(type) {
+			case *ast.IndexExpr, *ast.IndexListExpr:
+				// an alias of an instantiation points to the generic type
+				aliasType = getGenericType(aliasType)
+			}
 			if !isPrimitiveString(typeName) {
 				typeName = fmt.Sprintf("%s.%s", p.currentPackageName, typeName)
 			}
+			// the type parameters belong to the declaration, which is kept under the qualified name
+			p.addTypeParams(typeName, v.TypeParams)
 			packageName := p.currentPackageName
 			if isPrimitiveString(basicType) {
 				packageName = builtinPackageName
 			}
+			switch b := getBasicType(c).(type) {
+			case *ast.Ident:
+				if isTypeParam(b.Name, v.TypeParams) {
+					packageName = builtinPackageName
+				}
+			case *ast.StructType, *ast.InterfaceType:
+				// an anonymous struct or interface does not belong to the package
+				packageName = builtinPackageName
+			}
 			alias = getNewAlias(fmt.Sprintf("%s.%s", packageName, aliasType), p.currentPackageName, typeName)
+			if resolved := p.resolvedTypeName(c); resolved != "" {
+				alias.Name = resolved
+			}
 
 		}
 	default:
@@ -360,6 +607,9 @@ func (p *ClassParser) processSpec(spec ast.Spec) {
 		return
 	}
 	p.getOrCreateStruct(typeName).Type = declarationType
+	if doc != nil {
+		p.getOrCreateStruct(typeName).Doc = strings.TrimSpace(doc.Text())
+	}
 	fullName := fmt.Sprintf("%s.%s", p.currentPackageName, typeName)
 	switch declarationType {
 	case "interface":
@@ -367,17 +617,44 @@ func (p *ClassParser) processSpec(spec ast.Spec) {
 	case "class":
 		p.allStructs[fullName] = struct{}{}
 	case "alias":
-		p.allAliases[typeName] = alias
-		if strings.Count(alias.Name, ".") > 1 {
-			pack := strings.SplitN(alias.Name, ".", 2)
-			if _, ok := p.allRenamedStructs[pack[0]]; !ok {
-				p.allRenamedStructs[pack[0]] = map[string]string{}
+		p.addAlias(typeName, alias)
+	}
+	return
+}
+
+//addTypeParams records the type parameters of a generic type declaration, if any
+func (p *ClassParser) addTypeParams(typeName string, params *ast.FieldList) {
+	if params != nil {
+		p.getOrCreateStruct(typeName).AddTypeParams(params, p.allImports)
+	}
+}
+
+//isTypeParam returns true if the name is one of the given type parameters
+func isTypeParam(name string, params *ast.FieldList) bool {
+	if params == nil {
+		return false
+	}
+	for _, param := range params.List {
+		for _, n := range param.Names {
+			if n.Name == name {
+				return true
 			}
-			renamedClass := generateRenamedStructName(pack[1])
-			p.allRenamedStructs[pack[0]][renamedClass] = pack[1]
 		}
 	}
-	return
+	return false
+}
+
+//addAlias records the alias of a defined type. A type whose name contains dots is declared under a
+//generated name, PlantUML would read them as namespaces otherwise.
+func (p *ClassParser) addAlias(typeName string, alias *Alias) {
+	p.allAliases[typeName] = alias
+	if pack, name := p.splitName(alias.Name); strings.Contains(name, ".") {
+		if _, ok := p.allRenamedStructs[pack]; !ok {
+			p.allRenamedStructs[pack] = map[string]string{}
+		}
+		renamedClass := generateRenamedStructName(name)
+		p.allRenamedStructs[pack][renamedClass] = name
+	}
 }
 
 // If this element is an array or a pointer, this function will return the type that is closer to these
@@ -394,6 +671,10 @@ func getBasicType(theType ast.Expr) ast.Expr {
 		return getBasicType(t.Value)
 	case *ast.Ellipsis:
 		return getBasicType(t.Elt)
+	case *ast.IndexExpr:
+		return getBasicType(t.X)
+	case *ast.IndexListExpr:
+		return getBasicType(t.X)
 	}
 	return theType
 }
@@ -401,20 +682,19 @@ func getBasicType(theType ast.Expr) ast.Expr {
 //Render returns a string of the class diagram that this parser has generated.
 func (p *ClassParser) Render() string {
 	str := &LineStringBuilder{}
+	p.visible = p.visibleTypes()
+	defer func() { p.visible = nil }()
 
 	str.WriteLineWithDepth(0, "@startuml")
 
 	// render defaults style
-	if p.renderingOptions.Style != "" {
-		str.WriteLineWithDepth(0, fmt.Sprintf(`!includeurl %s`, p.renderingOptions.Style))
-	}
+	p.renderStyle(str)
 
 	if p.renderingOptions.Title != "" {
 		str.WriteLineWithDepth(0, fmt.Sprintf(`title %s`, p.renderingOptions.Title))
 	}
 
-	// should make this an option
-	str.WriteLineWithDepth(0, fmt.Sprintf("left to right direction"))
+	p.renderDirection(str)
 
 	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
 		str.WriteLineWithDepth(0, "legend")
@@ -435,6 +715,7 @@ func (p *ClassParser) Render() string {
 	if p.renderingOptions.Aliases {
 		p.renderAliases(str)
 	}
+	p.renderHiddenLinks(str)
 	if !p.renderingOptions.Fields {
 		str.WriteLineWithDepth(0, "hide fields")
 	}
@@ -446,16 +727,17 @@ func (p *ClassParser) Render() string {
 }
 
 func (p *ClassParser) renderStructures(pack string, structures map[string]*Struct, str *LineStringBuilder) {
-	if len(structures) > 0 {
+	names := []string{}
+	for name := range structures {
+		if isVisible(p.visible, diagramID(pack, name)) {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 || p.hasUtility(pack, p.visible) {
 		composition := &LineStringBuilder{}
 		extends := &LineStringBuilder{}
 		aggregations := &LineStringBuilder{}
-		str.WriteLineWithDepth(0, fmt.Sprintf(`namespace %s {`, pack))
-
-		names := []string{}
-		for name := range structures {
-			names = append(names, name)
-		}
+		str.WriteLineWithDepth(0, p.groupStart(pack))
 
 		sort.Strings(names)
 
@@ -463,9 +745,12 @@ func (p *ClassParser) renderStructures(pack string, structures map[string]*Struc
 			structure := structures[name]
 			p.renderStructure(structure, pack, name, str, composition, extends, aggregations)
 		}
+		created := p.renderUtility(pack, str)
 		var orderedRenamedStructs []string
-		for tempName := range p.allRenamedStructs[pack] {
-			orderedRenamedStructs = append(orderedRenamedStructs, tempName)
+		for tempName, name := range p.allRenamedStructs[pack] {
+			if isVisible(p.visible, pack+"."+name) {
+				orderedRenamedStructs = append(orderedRenamedStructs, tempName)
+			}
 		}
 		sort.Strings(orderedRenamedStructs)
 		for _, tempName := range orderedRenamedStructs {
@@ -484,6 +769,9 @@ func (p *ClassParser) renderStructures(pack string, structures map[string]*Struc
 		if p.renderingOptions.Aggregations {
 			str.WriteLineWithDepth(0, aggregations.String())
 		}
+		for _, c := range created {
+			str.WriteLineWithDepth(0, c)
+		}
 	}
 }
 
@@ -499,17 +787,19 @@ func (p *ClassParser) renderAliases(str *LineStringBuilder) {
 	}
 	sort.Sort(orderedAliases)
 	for _, alias := range orderedAliases {
+		if !isVisible(p.visible, alias.Name, alias.AliasOf) {
+			continue
+		}
 		aliasName := alias.Name
-		if strings.Count(alias.Name, ".") > 1 {
-			split := strings.SplitN(alias.Name, ".", 2)
-			if aliasRename, ok := p.allRenamedStructs[split[0]]; ok {
-				renamed := generateRenamedStructName(split[1])
+		if pack, name := p.splitName(alias.Name); strings.Contains(name, ".") {
+			if aliasRename, ok := p.allRenamedStructs[pack]; ok {
+				renamed := generateRenamedStructName(name)
 				if _, ok := aliasRename[renamed]; ok {
-					aliasName = fmt.Sprintf("%s.%s", split[0], renamed)
+					aliasName = fmt.Sprintf("%s.%s", pack, renamed)
 				}
 			}
 		}
-		str.WriteLineWithDepth(0, fmt.Sprintf(`"%s" #.. %s"%s"`, aliasName, aliasString, alias.AliasOf))
+		str.WriteLineWithDepth(0, fmt.Sprintf(`"%s" #.. %s"%s"`, p.renderName(aliasName), aliasString, p.renderName(alias.AliasOf)))
 	}
 }
 
@@ -527,14 +817,34 @@ func (p *ClassParser) renderStructure(structure *Struct, pack string, name strin
 	case "alias":
 		sType = "<< (T, #FF7700) >> "
 		renderStructureType = "class"
+		if len(p.enumConstants(pack, name, structure)) > 0 {
+			renderStructureType = "enum"
+		}
 
 	}
-	str.WriteLineWithDepth(1, fmt.Sprintf(`%s %s %s {`, renderStructureType, name, sType))
+	renderedName := name
+	if strings.Contains(name, ".") {
+		// aliases are kept under their package qualified name
+		renderedName = p.renderName(name)
+	}
+	str.WriteLineWithDepth(1, fmt.Sprintf(`%s %s%s%s %s%s {`, renderStructureType, renderedName, renderTypeParams(structure), p.typeDocLink(structure, name), sType, p.diffTypeColor(structure)))
 	p.renderStructFields(structure, privateFields, publicFields)
-	p.renderStructMethods(structure, privateMethods, publicMethods)
+	p.renderMethods(structure, name, privateMethods, publicMethods)
 	p.renderCompositions(structure, name, composition)
 	p.renderExtends(structure, name, extends)
 	p.renderAggregations(structure, name, aggregations)
+	if len(structure.TypeSet) > 0 {
+		for _, t := range structure.TypeSet {
+			str.WriteLineWithDepth(2, t)
+		}
+		str.WriteLineWithDepth(0, "")
+	}
+	if constants := p.enumConstants(pack, name, structure); len(constants) > 0 {
+		for _, c := range constants {
+			str.WriteLineWithDepth(2, renderConstant(c))
+		}
+		str.WriteLineWithDepth(0, "")
+	}
 	if privateFields.Len() > 0 {
 		str.WriteLineWithDepth(0, privateFields.String())
 	}
@@ -548,6 +858,20 @@ func (p *ClassParser) renderStructure(structure *Struct, pack string, name strin
 		str.WriteLineWithDepth(0, publicMethods.String())
 	}
 	str.WriteLineWithDepth(1, fmt.Sprintf(`}`))
+	p.renderDocNote(structure, renderedName, str)
+}
+
+//renderTypeParams returns the type parameters of a generic type with their constraints as a
+//PlantUML generic, e.g. <K comparable, V any>, or an empty string
+func renderTypeParams(structure *Struct) string {
+	if len(structure.TypeParams) == 0 {
+		return ""
+	}
+	params := make([]string, 0, len(structure.TypeParams))
+	for _, param := range structure.TypeParams {
+		params = append(params, fmt.Sprintf("%s %s", param.Name, param.Type))
+	}
+	return fmt.Sprintf("<%s>", strings.Join(params, ", "))
 }
 
 func (p *ClassParser) renderCompositions(structure *Struct, name string, composition *LineStringBuilder) {
@@ -557,11 +881,14 @@ func (p *ClassParser) renderCompositions(structure *Struct, name string, composi
 		if !strings.Contains(c, ".") {
 			c = fmt.Sprintf("%s.%s", p.getPackageName(c, structure), c)
 		}
+		if !isVisible(p.visible, c, diagramID(structure.PackageName, name)) {
+			continue
+		}
 		composedString := ""
 		if p.renderingOptions.ConnectionLabels {
 			composedString = extends
 		}
-		c = fmt.Sprintf(`"%s" *-- %s"%s.%s"`, c, composedString, structure.PackageName, name)
+		c = fmt.Sprintf(`"%s" *-- %s"%s"`, p.renderName(c), composedString, p.renderName(structure.PackageName+"."+name))
 		orderedCompositions = append(orderedCompositions, c)
 	}
 	sort.Strings(orderedCompositions)
@@ -602,8 +929,8 @@ func (p *ClassParser) renderAggregationMap(aggregationMap map[string]struct{}, s
 		if p.renderingOptions.ConnectionLabels {
 			aggregationString = aggregates
 		}
-		if p.getPackageName(a, structure) != builtinPackageName {
-			aggregations.WriteLineWithDepth(0, fmt.Sprintf(`"%s.%s"%s o-- "%s"`, structure.PackageName, name, aggregationString, a))
+		if p.getPackageName(a, structure) != builtinPackageName && isVisible(p.visible, diagramID(structure.PackageName, name), a) {
+			aggregations.WriteLineWithDepth(0, fmt.Sprintf(`"%s"%s o-- "%s"`, p.renderName(structure.PackageName+"."+name), aggregationString, p.renderName(a)))
 		}
 	}
 }
@@ -623,11 +950,14 @@ func (p *ClassParser) renderExtends(structure *Struct, name string, extends *Lin
 		if !strings.Contains(c, ".") {
 			c = fmt.Sprintf("%s.%s", structure.PackageName, c)
 		}
+		if !isVisible(p.visible, c, diagramID(structure.PackageName, name)) {
+			continue
+		}
 		implementString := ""
 		if p.renderingOptions.ConnectionLabels {
 			implementString = implements
 		}
-		c = fmt.Sprintf(`"%s" <|-- %s"%s.%s"`, c, implementString, structure.PackageName, name)
+		c = fmt.Sprintf(`"%s" <|-- %s"%s"`, p.renderName(c), implementString, p.renderName(structure.PackageName+"."+name))
 		orderedExtends = append(orderedExtends, c)
 	}
 	sort.Strings(orderedExtends)
@@ -637,6 +967,11 @@ func (p *ClassParser) renderExtends(structure *Struct, name string, extends *Lin
 }
 
 func (p *ClassParser) renderStructMethods(structure *Struct, privateMethods *LineStringBuilder, publicMethods *LineStringBuilder) {
+	p.renderMethods(structure, "", privateMethods, publicMethods)
+}
+
+//renderMethods writes the methods of the type typeName, linked to their documentation with the RenderMethodDocs option
+func (p *ClassParser) renderMethods(structure *Struct, typeName string, privateMethods *LineStringBuilder, publicMethods *LineStringBuilder) {
 
 	for _, method := range structure.Functions {
 		accessModifier := "+"
@@ -655,10 +990,12 @@ func (p *ClassParser) renderStructMethods(structure *Struct, privateMethods *Lin
 				returnValues = fmt.Sprintf("(%s)", strings.Join(method.ReturnValues, ", "))
 			}
 		}
+		link := p.methodDocLink(structure, typeName, method)
+		signature := p.diffMethod(structure, method.Name, fmt.Sprintf(`%s(%s) %s`, method.Name, strings.Join(parameterList, ", "), returnValues))
 		if accessModifier == "-" {
-			privateMethods.WriteLineWithDepth(2, fmt.Sprintf(`%s %s(%s) %s`, accessModifier, method.Name, strings.Join(parameterList, ", "), returnValues))
+			privateMethods.WriteLineWithDepth(2, fmt.Sprintf(`%s %s%s`, accessModifier, signature, link))
 		} else {
-			publicMethods.WriteLineWithDepth(2, fmt.Sprintf(`%s %s(%s) %s`, accessModifier, method.Name, strings.Join(parameterList, ", "), returnValues))
+			publicMethods.WriteLineWithDepth(2, fmt.Sprintf(`%s %s%s`, accessModifier, signature, link))
 		}
 	}
 }
@@ -669,10 +1006,15 @@ func (p *ClassParser) renderStructFields(structure *Struct, privateFields *LineS
 		if unicode.IsLower(rune(field.Name[0])) {
 			accessModifier = "-"
 		}
+		tag := ""
+		if p.renderingOptions.Tags {
+			tag = renderTag(field.Tag)
+		}
+		text := p.diffField(structure, field.Name, fmt.Sprintf(`%s %s%s`, field.Name, field.Type, tag))
 		if accessModifier == "-" {
-			privateFields.WriteLineWithDepth(2, fmt.Sprintf(`%s %s %s`, accessModifier, field.Name, field.Type))
+			privateFields.WriteLineWithDepth(2, fmt.Sprintf(`%s %s`, accessModifier, text))
 		} else {
-			publicFields.WriteLineWithDepth(2, fmt.Sprintf(`%s %s %s`, accessModifier, field.Name, field.Type))
+			publicFields.WriteLineWithDepth(2, fmt.Sprintf(`%s %s`, accessModifier, text))
 		}
 	}
 }
@@ -698,12 +1040,12 @@ func (p *ClassParser) getOrCreateStruct(name string) *Struct {
 
 // Returns an existing struct only if it was created. nil otherwhise
 func (p *ClassParser) getStruct(structName string) *Struct {
-	split := strings.SplitN(structName, ".", 2)
-	pack, ok := p.structure[split[0]]
+	packageName, name := p.splitName(structName)
+	pack, ok := p.structure[packageName]
 	if !ok {
 		return nil
 	}
-	return pack[split[1]]
+	return pack[name]
 }
 
 //SetRenderingOptions Sets the rendering options for the Render() Function
@@ -732,6 +1074,71 @@ func (p *ClassParser) SetRenderingOptions(ro map[RenderingOption]interface{}) er
 			p.renderingOptions.AggregatePrivateMembers = val.(bool)
 		case RenderStyle:
 			p.renderingOptions.Style = val.(string)
+		case RenderNamespacePrefix:
+			p.renderingOptions.NamespacePrefix = val.(string)
+		case RenderExternalPackages:
+			p.renderingOptions.ExternalPackages = val.(bool)
+		case RenderPackageCycles:
+			p.renderingOptions.PackageCycles = val.(bool)
+		case RenderFocus:
+			focus := val.(string)
+			if focus != "" {
+				resolved, err := p.resolveType(focus)
+				if err != nil {
+					return err
+				}
+				focus = resolved
+			}
+			p.renderingOptions.Focus = focus
+		case RenderFocusDepth:
+			p.renderingOptions.FocusDepth = val.(int)
+		case RenderInclude:
+			if _, err := compileTypeFilters(val.([]string)); err != nil {
+				return err
+			}
+			p.renderingOptions.Include = val.([]string)
+		case RenderDocs:
+			docs := val.(string)
+			if docs != "" && docs != DocsSummary && docs != DocsFull {
+				return fmt.Errorf("Invalid doc comments option %s, expected %s or %s", docs, DocsSummary, DocsFull)
+			}
+			p.renderingOptions.Docs = docs
+		case RenderMethodDocs:
+			p.renderingOptions.MethodDocs = val.(bool)
+		case RenderTags:
+			p.renderingOptions.Tags = val.(bool)
+		case RenderEnums:
+			p.renderingOptions.Enums = val.(bool)
+		case RenderUtilities:
+			p.renderingOptions.Utilities = val.(bool)
+		case RenderTheme:
+			if name := val.(string); name != "" {
+				if _, err := theme(name); err != nil {
+					return err
+				}
+			}
+			p.renderingOptions.Theme = val.(string)
+		case RenderDirection:
+			if err := checkLayout(val.(string), ""); err != nil {
+				return err
+			}
+			p.renderingOptions.Direction = val.(string)
+		case RenderGrouping:
+			if err := checkLayout("", val.(string)); err != nil {
+				return err
+			}
+			p.renderingOptions.Grouping = val.(string)
+		case RenderHiddenLinks:
+			links, err := p.resolveHiddenLinks(val.([]string))
+			if err != nil {
+				return err
+			}
+			p.renderingOptions.HiddenLinks = links
+		case RenderExclude:
+			if _, err := compileTypeFilters(val.([]string)); err != nil {
+				return err
+			}
+			p.renderingOptions.Exclude = val.([]string)
 		default:
 			return fmt.Errorf("Invalid Rendering option %v", option)
 		}
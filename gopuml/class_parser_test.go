@@ -2,9 +2,16 @@ package gopuml
 
 import (
 	"go/ast"
+	"go/build"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"sort"
 	"testing"
+
+	"github.com/spf13/afero"
 )
 
 func TestLineBuilder(t *testing.T) {
@@ -1029,3 +1036,349 @@ namespace parenthesizedtypedeclarations {
 	}
 
 }
+
+// writeTestModule writes the given files into a temporary directory and returns its path
+func writeTestModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+var genericsModule = map[string]string{
+	"generics.go": `package generics
+
+type Number interface {
+	~int | ~float64
+}
+
+type Item struct{}
+
+type Map[K comparable, V any] struct {
+	items map[K]V
+	Keys  []K
+}
+
+func (m *Map[K, V]) Get(key K) V {
+	var v V
+	return v
+}
+
+type Sum[T Number] struct {
+	Map[string, T]
+	Total T
+}
+
+type Cache struct {
+	entries Map[string, *Item]
+	Pages   []Map[int, Item]
+}
+
+type Container[T any] interface {
+	Get(key int) T
+}
+
+type Store interface {
+	Container[Item]
+}
+
+type Set[T comparable] map[T]struct{}
+
+type List[T any] []T
+`,
+}
+
+func TestGenerics(t *testing.T) {
+	parser, err := NewClassDiagram([]string{writeTestModule(t, genericsModule)}, nil, false)
+	if err != nil {
+		t.Fatalf("TestGenerics: expected no error, got %s", err)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{
+		RenderAggregations:      true,
+		AggregatePrivateMembers: true,
+	})
+	// the type parameters are not connected to types of the package
+	expected := `@startuml
+left to right direction
+namespace generics {
+    class Cache << (S,Aquamarine) >> {
+        - entries Map[string, *Item]
+
+        + Pages []Map[int, Item]
+
+    }
+    interface Container<T any>  {
+        + Get(key int) T
+
+    }
+    class Item << (S,Aquamarine) >> {
+    }
+    class Map<K comparable, V any> << (S,Aquamarine) >> {
+        - items <font color=blue>map</font>[K]V
+
+        + Keys []K
+
+        + Get(key K) V
+
+    }
+    interface Number  {
+        ~int | ~float64
+
+    }
+    interface Store  {
+    }
+    class Sum<T Number> << (S,Aquamarine) >> {
+        + Total T
+
+    }
+    class generics.List<T any> << (T, #FF7700) >>  {
+    }
+    class generics.Set<T comparable> << (T, #FF7700) >>  {
+    }
+}
+"generics.Container" *-- "generics.Store"
+"generics.Map" *-- "generics.Sum"
+
+
+"generics.Cache" o-- "generics.Item"
+"generics.Cache" o-- "generics.Map"
+
+"__builtin__.<font color=blue>map</font>[T]<font color=blue>struct</font>{}" #.. "generics.Set"
+"__builtin__.[]T" #.. "generics.List"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestGenerics: expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestBuildConstraints(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/conn/conn.go":         "package conn\n\ntype Conn struct{}\n",
+		"/conn/conn_linux.go":   "package conn\n\nfunc (c *Conn) Linux() {}\n",
+		"/conn/conn_darwin.go":  "package conn\n\nfunc (c *Conn) Darwin() {}\n",
+		"/conn/conn_arm64.go":   "package conn\n\nfunc (c *Conn) Arm64() {}\n",
+		"/conn/debug.go":        "//go:build debug\n\npackage conn\n\nfunc (c *Conn) Debug() {}\n",
+		"/conn/conn_test.go":    "package conn\n\nfunc (c *Conn) Test() {}\n",
+		"/conn/ignore_other.go": "//go:build ignore\n\npackage main\n",
+	}
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	tt := []struct {
+		Name            string
+		Options         ClassDiagramOptions
+		ExpectedMethods []string
+	}{
+		{
+			Name:            "linux amd64",
+			Options:         ClassDiagramOptions{GOOS: "linux", GOARCH: "amd64"},
+			ExpectedMethods: []string{"Linux"},
+		},
+		{
+			Name:            "darwin arm64 with tags",
+			Options:         ClassDiagramOptions{GOOS: "darwin", GOARCH: "arm64", BuildTags: []string{"debug"}},
+			ExpectedMethods: []string{"Arm64", "Darwin", "Debug"},
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			options := tc.Options
+			options.FileSystem = fs
+			options.Directories = []string{"/conn"}
+			parser, err := NewClassDiagramWithOptions(&options)
+			if err != nil {
+				t.Fatalf("TestBuildConstraints: expected no error, got %s", err)
+			}
+			if _, ok := parser.structure["main"]; ok {
+				t.Errorf("TestBuildConstraints: expected files with the ignore tag to be skipped")
+			}
+			var methods []string
+			for _, f := range parser.getStruct("conn.Conn").Functions {
+				methods = append(methods, f.Name)
+			}
+			sort.Strings(methods)
+			if !reflect.DeepEqual(methods, tc.ExpectedMethods) {
+				t.Errorf("TestBuildConstraints: expected methods %v, got %v", tc.ExpectedMethods, methods)
+			}
+		})
+	}
+}
+
+func TestBuildConstraintsCgo(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"/conn/conn.go": "package conn\n\ntype Conn struct{}\n",
+		"/conn/cgo.go":  "//go:build cgo\n\npackage conn\n\nfunc (c *Conn) Cgo() {}\n",
+	}
+	for name, content := range files {
+		if err := afero.WriteFile(fs, name, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cross := "windows"
+	if runtime.GOOS == cross {
+		cross = "linux"
+	}
+	tt := []struct {
+		Name        string
+		GOOS        string
+		GOARCH      string
+		CgoEnabled  string
+		ExpectedCgo bool
+	}{
+		{Name: "host", GOOS: runtime.GOOS, GOARCH: runtime.GOARCH, ExpectedCgo: build.Default.CgoEnabled},
+		{Name: "cross", GOOS: cross, GOARCH: "amd64", ExpectedCgo: false},
+		{Name: "cross with CGO_ENABLED=1", GOOS: cross, GOARCH: "amd64", CgoEnabled: "1", ExpectedCgo: true},
+	}
+	for _, tc := range tt {
+		t.Run(tc.Name, func(t *testing.T) {
+			t.Setenv("CGO_ENABLED", tc.CgoEnabled)
+			parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+				FileSystem:  fs,
+				Directories: []string{"/conn"},
+				GOOS:        tc.GOOS,
+				GOARCH:      tc.GOARCH,
+			})
+			if err != nil {
+				t.Fatalf("TestBuildConstraintsCgo: expected no error, got %s", err)
+			}
+			cgo := len(parser.getStruct("conn.Conn").Functions) == 1
+			if cgo != tc.ExpectedCgo {
+				t.Errorf("TestBuildConstraintsCgo: expected the cgo file to be selected %t, got %t", tc.ExpectedCgo, cgo)
+			}
+		})
+	}
+}
+
+func TestPackagesKeyedByImportPath(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod":                 "module example.com/mono\n",
+		"a/internal/internal.go": "package internal\n\ntype A struct{}\n",
+		"b/internal/internal.go": "package internal\n\ntype B struct{}\n",
+		"app/app.go": `package app
+
+import (
+	ai "example.com/mono/a/internal"
+	"gopkg.in/yaml.v3"
+)
+
+type App struct {
+	ai.A
+	Node *yaml.Node
+}
+`,
+	})
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestPackagesKeyedByImportPath: expected no error, got %s", err)
+	}
+	for _, name := range []string{"example.com/mono/a/internal.A", "example.com/mono/b/internal.B", "example.com/mono/app.App"} {
+		if parser.getStruct(name) == nil {
+			t.Errorf("TestPackagesKeyedByImportPath: expected %s to exist", name)
+		}
+	}
+	if parser.getStruct("example.com/mono/a/internal.B") != nil {
+		t.Errorf("TestPackagesKeyedByImportPath: expected the internal packages not to be merged")
+	}
+	app := parser.getStruct("example.com/mono/app.App")
+	if _, ok := app.Composition["example.com/mono/a/internal.A"]; !ok {
+		t.Errorf("TestPackagesKeyedByImportPath: expected a composition with the import path, got %v", app.Composition)
+	}
+	if _, ok := app.Aggregations["gopkg.in/yaml.v3.Node"]; !ok || app.Fields[0].Type != "*yaml.Node" {
+		t.Errorf("TestPackagesKeyedByImportPath: expected an aggregation of gopkg.in/yaml.v3.Node shown as *yaml.Node, got %v %v", app.Aggregations, app.Fields[0])
+	}
+
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderAggregations: true})
+	expected := `@startuml
+left to right direction
+namespace example.com.mono.a.internal {
+    class A << (S,Aquamarine) >> {
+    }
+}
+
+
+
+namespace example.com.mono.app {
+    class App << (S,Aquamarine) >> {
+        + Node *yaml.Node
+
+    }
+}
+"example.com.mono.a.internal.A" *-- "example.com.mono.app.App"
+
+
+"example.com.mono.app.App" o-- "gopkg.in.yaml.v3.Node"
+
+namespace example.com.mono.b.internal {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestPackagesKeyedByImportPath: expected\n%s\ngot\n%s", expected, result)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderNamespacePrefix: "example.com/mono/"})
+	expected = `@startuml
+left to right direction
+namespace a.internal {
+    class A << (S,Aquamarine) >> {
+    }
+}
+
+
+
+namespace app {
+    class App << (S,Aquamarine) >> {
+        + Node *yaml.Node
+
+    }
+}
+"a.internal.A" *-- "app.App"
+
+
+"app.App" o-- "gopkg.in.yaml.v3.Node"
+
+namespace b.internal {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestPackagesKeyedByImportPath: expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestPackageNameOf(t *testing.T) {
+	tt := map[string]string{
+		"fmt":                                "fmt",
+		"github.com/spf13/afero":             "afero",
+		"gopkg.in/yaml.v3":                   "yaml",
+		"github.com/plgd-dev/go-coap/v3/udp": "udp",
+		"github.com/BurntSushi/toml/v2":      "toml",
+	}
+	for input, expected := range tt {
+		if result := packageNameOf(input); result != expected {
+			t.Errorf("TestPackageNameOf: expected %s for %s, got %s", expected, input, result)
+		}
+	}
+}
@@ -0,0 +1,173 @@
+package gopuml
+
+import (
+	"fmt"
+	"reflect"
+)
+
+const diffAdded = "added"
+const diffRemoved = "removed"
+const diffChanged = "changed"
+
+//diffTypeColors are the PlantUML background colors of the types of a diff
+var diffTypeColors = map[string]string{
+	diffAdded:   "#PaleGreen",
+	diffRemoved: "#Pink",
+	diffChanged: "#Moccasin",
+}
+
+//diffMemberColors are the PlantUML text colors of the fields and methods of a diff
+var diffMemberColors = map[string]string{
+	diffAdded:   "green",
+	diffRemoved: "red",
+	diffChanged: "orange",
+}
+
+//structDiff holds how a type and its members changed between two revisions. Fields and methods are
+//identified by their names.
+type structDiff struct {
+	Status  string
+	Fields  map[string]string
+	Methods map[string]string
+}
+
+//Diff merges the types of the class diagram of an older revision into the diagram, to render them both:
+//added types and members are green, removed ones red and the fields and methods whose type or signature
+//changed orange. The types, fields and methods of the older revision are added to the parser.
+func (p *ClassParser) Diff(old *ClassParser) {
+	p.diff = make(map[*Struct]*structDiff)
+	for pack, structures := range p.structure {
+		for name, structure := range structures {
+			if _, ok := old.structure[pack][name]; !ok {
+				p.diff[structure] = &structDiff{Status: diffAdded}
+			}
+		}
+	}
+	for pack, structures := range old.structure {
+		if _, ok := p.structure[pack]; !ok {
+			p.structure[pack] = make(map[string]*Struct)
+		}
+		for name, oldStructure := range structures {
+			structure, ok := p.structure[pack][name]
+			if !ok {
+				p.structure[pack][name] = oldStructure
+				p.diff[oldStructure] = &structDiff{Status: diffRemoved}
+				if alias, ok := old.allAliases[name]; ok {
+					p.allAliases[name] = alias
+				}
+				continue
+			}
+			d := diffMembers(oldStructure, structure)
+			newAlias, oldAlias := p.allAliases[name], old.allAliases[name]
+			if structure.Type != oldStructure.Type || (newAlias != nil && oldAlias != nil && newAlias.AliasOf != oldAlias.AliasOf) {
+				d.Status = diffChanged
+			}
+			if d.Status != "" || len(d.Fields) > 0 || len(d.Methods) > 0 {
+				p.diff[structure] = d
+			}
+		}
+	}
+	for pack, renamed := range old.allRenamedStructs {
+		if _, ok := p.allRenamedStructs[pack]; !ok {
+			p.allRenamedStructs[pack] = make(map[string]string)
+		}
+		for tempName, name := range renamed {
+			p.allRenamedStructs[pack][tempName] = name
+		}
+	}
+	for pack, oldUtility := range old.utilities {
+		utility, ok := p.utilities[pack]
+		if !ok {
+			p.utilities[pack] = oldUtility
+			p.diff[oldUtility] = &structDiff{Status: diffRemoved}
+			continue
+		}
+		if d := diffMembers(oldUtility, utility); len(d.Fields) > 0 || len(d.Methods) > 0 {
+			p.diff[utility] = d
+		}
+	}
+	for pack, utility := range p.utilities {
+		if _, ok := old.utilities[pack]; !ok {
+			p.diff[utility] = &structDiff{Status: diffAdded}
+		}
+	}
+}
+
+//diffMembers compares the fields and methods of the two revisions of a type, and adds the removed ones
+//to the newer one
+func diffMembers(old, new *Struct) *structDiff {
+	d := &structDiff{Fields: make(map[string]string), Methods: make(map[string]string)}
+	oldFields := make(map[string]*Field)
+	for _, f := range old.Fields {
+		oldFields[f.Name] = f
+	}
+	newFields := make(map[string]bool)
+	for _, f := range new.Fields {
+		newFields[f.Name] = true
+		oldField, ok := oldFields[f.Name]
+		if !ok {
+			d.Fields[f.Name] = diffAdded
+		} else if oldField.Type != f.Type || oldField.Tag != f.Tag {
+			d.Fields[f.Name] = diffChanged
+		}
+	}
+	for _, f := range old.Fields {
+		if !newFields[f.Name] {
+			d.Fields[f.Name] = diffRemoved
+			new.Fields = append(new.Fields, f)
+		}
+	}
+
+	oldMethods := make(map[string]*Function)
+	for _, m := range old.Functions {
+		oldMethods[m.Name] = m
+	}
+	newMethods := make(map[string]bool)
+	for _, m := range new.Functions {
+		newMethods[m.Name] = true
+		oldMethod, ok := oldMethods[m.Name]
+		if !ok {
+			d.Methods[m.Name] = diffAdded
+		} else if !m.SignturesAreEqual(oldMethod) || !reflect.DeepEqual(m.ReturnValues, oldMethod.ReturnValues) {
+			d.Methods[m.Name] = diffChanged
+		}
+	}
+	for _, m := range old.Functions {
+		if !newMethods[m.Name] {
+			d.Methods[m.Name] = diffRemoved
+			new.Functions = append(new.Functions, m)
+		}
+	}
+	return d
+}
+
+//diffTypeColor returns the PlantUML color of a type that was added, removed or changed, after a space
+func (p *ClassParser) diffTypeColor(structure *Struct) string {
+	if d, ok := p.diff[structure]; ok && d.Status != "" {
+		return " " + diffTypeColors[d.Status]
+	}
+	return ""
+}
+
+//diffField returns the text of a field, colored when the field was added, removed or changed
+func (p *ClassParser) diffField(structure *Struct, name, text string) string {
+	if d, ok := p.diff[structure]; ok {
+		return diffText(d.Fields[name], text)
+	}
+	return text
+}
+
+//diffMethod returns the text of a method, colored when the method was added, removed or changed
+func (p *ClassParser) diffMethod(structure *Struct, name, text string) string {
+	if d, ok := p.diff[structure]; ok {
+		return diffText(d.Methods[name], text)
+	}
+	return text
+}
+
+func diffText(status, text string) string {
+	if status == "" {
+		return text
+	}
+	return fmt.Sprintf("<color:%s>%s</color>", diffMemberColors[status], text)
+}
@@ -0,0 +1,141 @@
+package gopuml
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+var diffOldModule = map[string]string{
+	"go.mod": "module example.com/shop\n",
+	"shop.go": `package shop
+
+type Cart struct {
+	Items []string
+	Owner string
+	total int
+}
+
+func (c *Cart) Add(item string) {}
+
+func (c *Cart) Total() int { return 0 }
+
+type Coupon struct{}
+`,
+}
+
+var diffNewModule = map[string]string{
+	"go.mod": "module example.com/shop\n",
+	"shop.go": `package shop
+
+type Cart struct {
+	Items []string
+	Owner int
+	Discount float64
+}
+
+func (c *Cart) Add(item string) {}
+
+func (c *Cart) Total() float64 { return 0 }
+
+func (c *Cart) Clear() {}
+
+type Order struct{}
+`,
+}
+
+func TestDiff(t *testing.T) {
+	oldParser, err := NewClassDiagram([]string{writeTestModule(t, diffOldModule)}, nil, true)
+	if err != nil {
+		t.Fatalf("TestDiff: expected no error, got %s", err)
+	}
+	parser, err := NewClassDiagram([]string{writeTestModule(t, diffNewModule)}, nil, true)
+	if err != nil {
+		t.Fatalf("TestDiff: expected no error, got %s", err)
+	}
+	parser.Diff(oldParser)
+	expected := `@startuml
+left to right direction
+namespace example.com.shop {
+    class Cart << (S,Aquamarine) >> {
+        - <color:red>total int</color>
+
+        + Items []string
+        + <color:orange>Owner int</color>
+        + <color:green>Discount float64</color>
+
+        + Add(item string) 
+        + <color:orange>Total() float64</color>
+        + <color:green>Clear() </color>
+
+    }
+    class Coupon << (S,Aquamarine) >> #Pink {
+    }
+    class Order << (S,Aquamarine) >> #PaleGreen {
+    }
+}
+
+
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestDiff: expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestNewGitFs(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	dir := writeTestModule(t, diffOldModule)
+	dir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		t.Fatalf("TestNewGitFs: expected no error, got %s", err)
+	}
+	for _, args := range [][]string{
+		{"init", "-q"},
+		{"add", "."},
+		{"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "-q", "-m", "old"},
+	} {
+		if out, err := exec.Command("git", append([]string{"-C", dir}, args...)...).CombinedOutput(); err != nil {
+			t.Fatalf("TestNewGitFs: git %v failed: %s %s", args, err, out)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "shop.go"), []byte(diffNewModule["shop.go"]), 0644); err != nil {
+		t.Fatalf("TestNewGitFs: expected no error, got %s", err)
+	}
+
+	fs, err := NewGitFs(dir, "HEAD")
+	if err != nil {
+		t.Fatalf("TestNewGitFs: expected no error, got %s", err)
+	}
+	content, err := afero.ReadFile(fs, filepath.Join(dir, "shop.go"))
+	if err != nil {
+		t.Fatalf("TestNewGitFs: expected no error, got %s", err)
+	}
+	if string(content) != diffOldModule["shop.go"] {
+		t.Errorf("TestNewGitFs: expected the committed file, got %s", content)
+	}
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		FileSystem:       fs,
+		Directories:      []string{dir},
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("TestNewGitFs: expected no error, got %s", err)
+	}
+	committed, err := NewClassDiagram([]string{writeTestModule(t, diffOldModule)}, nil, false)
+	if err != nil {
+		t.Fatalf("TestNewGitFs: expected no error, got %s", err)
+	}
+	if expected, result := committed.Render(), parser.Render(); result != expected {
+		t.Errorf("TestNewGitFs: expected the diagram of the committed revision\n%s\ngot\n%s", expected, result)
+	}
+
+	if _, err := NewGitFs(dir, "unknown"); err == nil {
+		t.Errorf("TestNewGitFs: expected an error for an unknown revision")
+	}
+}
@@ -0,0 +1,76 @@
+package gopuml
+
+import (
+	"fmt"
+	"go/doc"
+	"strings"
+)
+
+//DocsSummary is a value of the RenderDocs option that renders the first sentence of the doc comments
+const DocsSummary = "summary"
+
+//DocsFull is a value of the RenderDocs option that renders the whole doc comments
+const DocsFull = "full"
+
+//docsURL is where the links of the method tooltips point to, followed by the import path and the anchor
+const docsURL = "https://pkg.go.dev/"
+
+//renderedDoc returns the part of a doc comment the RenderDocs option selects, or an empty string
+func (p *ClassParser) renderedDoc(text string) string {
+	switch p.renderingOptions.Docs {
+	case DocsSummary:
+		return summary(text)
+	case DocsFull:
+		return strings.TrimSpace(text)
+	}
+	return ""
+}
+
+//summary returns the first sentence of a doc comment
+func summary(text string) string {
+	return (&doc.Package{}).Synopsis(text)
+}
+
+//tooltip returns the first sentence of a doc comment without the characters that end PlantUML links
+func tooltip(text string) string {
+	return strings.NewReplacer("{", "(", "}", ")", "[", "(", "]", ")").Replace(summary(text))
+}
+
+//typeDocLink returns the PlantUML link of a type with its doc comment as the tooltip, shown with the
+//RenderMethodDocs option like the links of its methods
+func (p *ClassParser) typeDocLink(structure *Struct, typeName string) string {
+	if !p.renderingOptions.MethodDocs || structure.Doc == "" || strings.Contains(typeName, ".") {
+		return ""
+	}
+	return fmt.Sprintf(" [[%s%s#%s{%s}]]", docsURL, structure.PackageName, typeName, tooltip(structure.Doc))
+}
+
+//methodDocLink returns the PlantUML link of a method with its doc comment as the tooltip, to be appended
+//to the method in the class body, or an empty string when the method has no doc comment
+func (p *ClassParser) methodDocLink(structure *Struct, typeName string, method *Function) string {
+	if !p.renderingOptions.MethodDocs || method.Doc == "" {
+		return ""
+	}
+	url := docsURL + structure.PackageName
+	if typeName != "" && !strings.Contains(typeName, ".") {
+		url += fmt.Sprintf("#%s.%s", typeName, method.Name)
+	}
+	return fmt.Sprintf(" [[[%s{%s}]]]", url, tooltip(method.Doc))
+}
+
+//renderDocNote writes the doc comment of a type as a note attached to it
+func (p *ClassParser) renderDocNote(structure *Struct, renderedName string, str *LineStringBuilder) {
+	text := p.renderedDoc(structure.Doc)
+	if text == "" {
+		return
+	}
+	str.WriteLineWithDepth(1, fmt.Sprintf("note top of %s", renderedName))
+	for _, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "end note" {
+			// a line that closes the note in PlantUML
+			line = " " + line
+		}
+		str.WriteLineWithDepth(1, line)
+	}
+	str.WriteLineWithDepth(1, "end note")
+}
@@ -0,0 +1,145 @@
+package gopuml
+
+import "testing"
+
+var docsModule = map[string]string{
+	"go.mod": "module example.com/docs\n",
+	"docs.go": `package docs
+
+// Client sends requests to a server. It retries
+// failed requests.
+type Client struct{}
+
+// Send sends a request.
+func (c *Client) Send() {}
+
+func (c *Client) Close() {}
+
+type (
+	// Grouped is declared in a group.
+	Grouped struct{}
+	Other   struct{}
+)
+`,
+}
+
+func TestDocs(t *testing.T) {
+	dir := writeTestModule(t, docsModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestDocs: expected no error, got %s", err)
+	}
+	tt := []struct {
+		Options  map[RenderingOption]interface{}
+		Expected string
+	}{
+		{
+			Options: map[RenderingOption]interface{}{},
+			Expected: `@startuml
+left to right direction
+namespace example.com.docs {
+    class Client << (S,Aquamarine) >> {
+        + Send() 
+        + Close() 
+
+    }
+    class Grouped << (S,Aquamarine) >> {
+    }
+    class Other << (S,Aquamarine) >> {
+    }
+}
+
+
+@enduml
+`,
+		},
+		{
+			Options: map[RenderingOption]interface{}{RenderDocs: DocsSummary},
+			Expected: `@startuml
+left to right direction
+namespace example.com.docs {
+    class Client << (S,Aquamarine) >> {
+        + Send() 
+        + Close() 
+
+    }
+    note top of Client
+    Client sends requests to a server.
+    end note
+    class Grouped << (S,Aquamarine) >> {
+    }
+    note top of Grouped
+    Grouped is declared in a group.
+    end note
+    class Other << (S,Aquamarine) >> {
+    }
+}
+
+
+@enduml
+`,
+		},
+		{
+			Options: map[RenderingOption]interface{}{RenderDocs: DocsFull},
+			Expected: `@startuml
+left to right direction
+namespace example.com.docs {
+    class Client << (S,Aquamarine) >> {
+        + Send() 
+        + Close() 
+
+    }
+    note top of Client
+    Client sends requests to a server. It retries
+    failed requests.
+    end note
+    class Grouped << (S,Aquamarine) >> {
+    }
+    note top of Grouped
+    Grouped is declared in a group.
+    end note
+    class Other << (S,Aquamarine) >> {
+    }
+}
+
+
+@enduml
+`,
+		},
+		{
+			Options: map[RenderingOption]interface{}{RenderMethodDocs: true},
+			Expected: `@startuml
+left to right direction
+namespace example.com.docs {
+    class Client [[https://pkg.go.dev/example.com/docs#Client{Client sends requests to a server.}]] << (S,Aquamarine) >> {
+        + Send()  [[[https://pkg.go.dev/example.com/docs#Client.Send{Send sends a request.}]]]
+        + Close() 
+
+    }
+    class Grouped [[https://pkg.go.dev/example.com/docs#Grouped{Grouped is declared in a group.}]] << (S,Aquamarine) >> {
+    }
+    class Other << (S,Aquamarine) >> {
+    }
+}
+
+
+@enduml
+`,
+		},
+	}
+	for _, tc := range tt {
+		if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderDocs: "", RenderMethodDocs: false}); err != nil {
+			t.Fatalf("TestDocs: expected no error, got %s", err)
+		}
+		if err := parser.SetRenderingOptions(tc.Options); err != nil {
+			t.Fatalf("TestDocs: expected no error, got %s", err)
+		}
+		if result := parser.Render(); result != tc.Expected {
+			t.Errorf("TestDocs: expected with %v\n%s\ngot\n%s", tc.Options, tc.Expected, result)
+		}
+	}
+
+	if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderDocs: "all"}); err == nil {
+		t.Errorf("TestDocs: expected an error for an invalid doc comments option")
+	}
+}
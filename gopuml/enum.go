@@ -0,0 +1,179 @@
+package gopuml
+
+import (
+	"fmt"
+	"go/ast"
+	"go/constant"
+	"go/token"
+	"go/types"
+)
+
+//Constant is a constant declared with a named type of its package, e.g. an iota value of a state
+type Constant struct {
+	Name  string
+	Value string
+}
+
+//handleConstDecl records the constants of a const declaration in the utility class of the package, and the
+//typed ones under their named type. A spec
+//without type and values repeats the type and expressions of the previous one, with the next iota.
+func (p *ClassParser) handleConstDecl(decl *ast.GenDecl) {
+	var typeName string
+	var typ ast.Expr
+	var values []ast.Expr
+	known := make(map[string]constant.Value)
+	for i, spec := range decl.Specs {
+		v, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		if v.Type != nil || v.Values != nil {
+			typeName = ""
+			if ident, ok := v.Type.(*ast.Ident); ok && !isPrimitive(ident) {
+				typeName = ident.Name
+			}
+			typ = v.Type
+			values = v.Values
+		}
+		for j, name := range v.Names {
+			var value constant.Value
+			if obj, ok := p.constObject(name); ok {
+				value = obj.Val()
+			} else if j < len(values) {
+				value = evalConstant(values[j], int64(i), known)
+			}
+			if value != nil {
+				known[name.Name] = value
+			}
+			if name.Name == "_" {
+				continue
+			}
+			c := &Constant{Name: name.Name}
+			if value != nil {
+				c.Value = value.ExactString()
+			} else if v.Values != nil && j < len(v.Values) {
+				// e.g. 5 * time.Second
+				c.Value = types.ExprString(v.Values[j])
+			}
+			p.addUtilityConstant(name, typ, c)
+			if typeName != "" {
+				p.addConstant(typeName, c)
+			}
+		}
+	}
+}
+
+//constObject returns the constant a name declares in type checked mode
+func (p *ClassParser) constObject(name *ast.Ident) (*types.Const, bool) {
+	if p.typeInfo == nil {
+		return nil, false
+	}
+	obj, ok := p.typeInfo.Defs[name].(*types.Const)
+	return obj, ok
+}
+
+//addConstant records a constant of a named type of the current package
+func (p *ClassParser) addConstant(typeName string, c *Constant) {
+	// named types that are not structs or interfaces are kept under their package qualified name
+	typeName = fmt.Sprintf("%s.%s", p.currentPackageName, typeName)
+	if _, ok := p.constants[p.currentPackageName]; !ok {
+		p.constants[p.currentPackageName] = make(map[string][]*Constant)
+	}
+	p.constants[p.currentPackageName][typeName] = append(p.constants[p.currentPackageName][typeName], c)
+}
+
+//enumConstants returns the constants of a named type rendered as an enum, or nil
+func (p *ClassParser) enumConstants(pack, name string, structure *Struct) []*Constant {
+	if !p.renderingOptions.Enums || structure.Type != "alias" {
+		return nil
+	}
+	return p.constants[pack][name]
+}
+
+//evalConstant returns the value of a constant expression of the parsed code, or nil when it depends on
+//something else than literals, iota and the previous constants of its declaration
+func evalConstant(expr ast.Expr, iota int64, known map[string]constant.Value) (value constant.Value) {
+	defer func() {
+		// go/constant panics on operands of the wrong kind and divisions by zero, which do not compile
+		if recover() != nil {
+			value = nil
+		}
+	}()
+	switch e := expr.(type) {
+	case *ast.BasicLit:
+		value = constant.MakeFromLiteral(e.Value, e.Kind, 0)
+	case *ast.Ident:
+		switch e.Name {
+		case "iota":
+			return constant.MakeInt64(iota)
+		case "true", "false":
+			return constant.MakeBool(e.Name == "true")
+		}
+		return known[e.Name]
+	case *ast.ParenExpr:
+		return evalConstant(e.X, iota, known)
+	case *ast.CallExpr:
+		// a conversion, e.g. State(1)
+		if len(e.Args) != 1 {
+			return nil
+		}
+		return evalConstant(e.Args[0], iota, known)
+	case *ast.UnaryExpr:
+		x := evalConstant(e.X, iota, known)
+		if x == nil {
+			return nil
+		}
+		value = constant.UnaryOp(e.Op, x, 0)
+	case *ast.BinaryExpr:
+		x := evalConstant(e.X, iota, known)
+		y := evalConstant(e.Y, iota, known)
+		if x == nil || y == nil {
+			return nil
+		}
+		if e.Op != token.SHL && e.Op != token.SHR && isNumeric(x) != isNumeric(y) {
+			// go/constant does not reject e.g. "a" + 1
+			return nil
+		}
+		switch e.Op {
+		case token.SHL, token.SHR:
+			s, ok := constant.Uint64Val(y)
+			if !ok {
+				return nil
+			}
+			value = constant.Shift(x, e.Op, uint(s))
+		case token.EQL, token.NEQ, token.LSS, token.LEQ, token.GTR, token.GEQ:
+			value = constant.MakeBool(constant.Compare(x, e.Op, y))
+		case token.QUO:
+			if x.Kind() == constant.Int && y.Kind() == constant.Int {
+				// integer division, like the compiler does for untyped integer constants
+				value = constant.BinaryOp(x, token.QUO_ASSIGN, y)
+			} else {
+				value = constant.BinaryOp(x, e.Op, y)
+			}
+		default:
+			value = constant.BinaryOp(x, e.Op, y)
+		}
+	default:
+		return nil
+	}
+	if value.Kind() == constant.Unknown {
+		return nil
+	}
+	return value
+}
+
+func isNumeric(v constant.Value) bool {
+	switch v.Kind() {
+	case constant.Int, constant.Float, constant.Complex:
+		return true
+	}
+	return false
+}
+
+//renderConstant returns the enum entry of a constant, with its value when it is known
+func renderConstant(c *Constant) string {
+	if c.Value == "" {
+		return c.Name
+	}
+	return fmt.Sprintf("%s = %s", c.Name, c.Value)
+}
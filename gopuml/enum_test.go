@@ -0,0 +1,187 @@
+package gopuml
+
+import (
+	"go/constant"
+	"go/parser"
+	"testing"
+)
+
+var enumModule = map[string]string{
+	"go.mod": "module example.com/wire\n",
+	"wire.go": `package wire
+
+type Data struct {
+	Name  string ` + "`json:\"name\" cbor:\"1,keyasint\"`" + `
+	State State
+	count int
+}
+
+const (
+	Idle State = iota
+	Running
+	_
+	Stopped
+)
+
+type State int
+
+type Flags uint8
+
+const (
+	FlagA Flags = 1 << iota
+	FlagB
+)
+
+const Version Kind = "v" + "1"
+
+type Kind string
+
+const untyped = 3
+`,
+}
+
+func TestEnumsAndTags(t *testing.T) {
+	dir := writeTestModule(t, enumModule)
+	// both modes evaluate the constants alike, untyped constants are not enum constants
+	expectedPlain := `@startuml
+left to right direction
+namespace example.com.wire {
+    class Data << (S,Aquamarine) >> {
+        - count int
+
+        + Name string
+        + State State
+
+    }
+    class example.com.wire.Flags << (T, #FF7700) >>  {
+    }
+    class example.com.wire.Kind << (T, #FF7700) >>  {
+    }
+    class example.com.wire.State << (T, #FF7700) >>  {
+    }
+}
+
+
+"__builtin__.int" #.. "example.com.wire.State"
+"__builtin__.string" #.. "example.com.wire.Kind"
+"__builtin__.uint8" #.. "example.com.wire.Flags"
+@enduml
+`
+	expected := `@startuml
+left to right direction
+namespace example.com.wire {
+    class Data << (S,Aquamarine) >> {
+        - count int
+
+        + Name string ` + "`" + `json:"name" cbor:"1,keyasint"` + "`" + `
+        + State State
+
+    }
+    enum example.com.wire.Flags << (T, #FF7700) >>  {
+        FlagA = 1
+        FlagB = 2
+
+    }
+    enum example.com.wire.Kind << (T, #FF7700) >>  {
+        Version = "v1"
+
+    }
+    enum example.com.wire.State << (T, #FF7700) >>  {
+        Idle = 0
+        Running = 1
+        Stopped = 3
+
+    }
+}
+
+
+"__builtin__.int" #.. "example.com.wire.State"
+"__builtin__.string" #.. "example.com.wire.Kind"
+"__builtin__.uint8" #.. "example.com.wire.Flags"
+@enduml
+`
+	expectedMermaid := `classDiagram
+    direction LR
+    namespace example_com_wire {
+        class example_com_wire_Data["Data"]
+        class example_com_wire_Flags["Flags"]
+        class example_com_wire_Kind["Kind"]
+        class example_com_wire_State["State"]
+    }
+    class int["int"]
+    class string["string"]
+    class uint8["uint8"]
+    example_com_wire_Data : -count int
+    example_com_wire_Data : +Name string ` + "`" + `json:#quot;name#quot; cbor:#quot;1,keyasint#quot;` + "`" + `
+    example_com_wire_Data : +State State
+    <<enum>> example_com_wire_Flags
+    example_com_wire_Flags : FlagA = 1
+    example_com_wire_Flags : FlagB = 2
+    <<enum>> example_com_wire_Kind
+    example_com_wire_Kind : Version = #quot;v1#quot;
+    <<enum>> example_com_wire_State
+    example_com_wire_State : Idle = 0
+    example_com_wire_State : Running = 1
+    example_com_wire_State : Stopped = 3
+    int .. example_com_wire_State
+    string .. example_com_wire_Kind
+    uint8 .. example_com_wire_Flags
+`
+	for _, typeChecked := range []bool{false, true} {
+		parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+			Directories:      []string{dir},
+			TypeChecked:      typeChecked,
+			RenderingOptions: map[RenderingOption]interface{}{},
+		})
+		if err != nil {
+			t.Fatalf("TestEnumsAndTags: expected no error, got %s", err)
+		}
+		if result := parser.Render(); result != expectedPlain {
+			t.Errorf("TestEnumsAndTags: expected without the options and types checked %t\n%s\ngot\n%s", typeChecked, expectedPlain, result)
+		}
+		if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderTags: true, RenderEnums: true}); err != nil {
+			t.Fatalf("TestEnumsAndTags: expected no error, got %s", err)
+		}
+		if result := parser.Render(); result != expected {
+			t.Errorf("TestEnumsAndTags: expected with types checked %t\n%s\ngot\n%s", typeChecked, expected, result)
+		}
+		if result := (MermaidRenderer{}).Render(parser); result != expectedMermaid {
+			t.Errorf("TestEnumsAndTags: expected the Mermaid diagram with types checked %t\n%s\ngot\n%s", typeChecked, expectedMermaid, result)
+		}
+	}
+}
+
+func TestEvalConstant(t *testing.T) {
+	known := map[string]constant.Value{"A": constant.MakeInt64(4)}
+	tt := []struct {
+		Expression string
+		Iota       int64
+		Expected   string
+	}{
+		{Expression: "iota", Iota: 2, Expected: "2"},
+		{Expression: "1 << iota", Iota: 3, Expected: "8"},
+		{Expression: "A * (iota + 1)", Iota: 1, Expected: "8"},
+		{Expression: "7 / 2", Expected: "3"},
+		{Expression: "State(-1)", Expected: "-1"},
+		{Expression: `"a" + "b"`, Expected: `"ab"`},
+		{Expression: "1 == 1", Expected: "true"},
+		{Expression: `"a" + 1`},
+		{Expression: "1 / 0"},
+		{Expression: "time.Second"},
+		{Expression: "len(B)"},
+	}
+	for _, tc := range tt {
+		expr, err := parser.ParseExpr(tc.Expression)
+		if err != nil {
+			t.Fatalf("TestEvalConstant: failed to parse %s: %s", tc.Expression, err)
+		}
+		value := evalConstant(expr, tc.Iota, known)
+		result := ""
+		if value != nil {
+			result = value.ExactString()
+		}
+		if result != tc.Expected {
+			t.Errorf("TestEvalConstant: expected %q for %s, got %q", tc.Expected, tc.Expression, result)
+		}
+	}
+}
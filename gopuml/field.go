@@ -14,6 +14,16 @@ type Field struct {
 	Name     string
 	Type     string
 	FullType string
+	// Tag is the struct tag of the field, without its quotes
+	Tag string
+}
+
+//renderTag returns a struct tag as it is written in Go, to be appended to its field
+func renderTag(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return fmt.Sprintf(" `%s`", tag)
 }
 
 //Returns a string representation of the given expression if it was recognized.
@@ -40,6 +50,14 @@ func getFieldType(exp ast.Expr, aliases map[string]string) (string, []string) {
 		return getFuncType(v, aliases)
 	case *ast.Ellipsis:
 		return getEllipsis(v, aliases)
+	case *ast.IndexExpr:
+		return getIndexExpr(v, aliases)
+	case *ast.IndexListExpr:
+		return getIndexListExpr(v, aliases)
+	case *ast.UnaryExpr:
+		return getUnaryExpr(v, aliases)
+	case *ast.BinaryExpr:
+		return getBinaryExpr(v, aliases)
 	}
 	return "", []string{}
 }
@@ -60,12 +78,14 @@ func getArrayType(v *ast.ArrayType, aliases map[string]string) (string, []string
 
 func getSelectorExp(v *ast.SelectorExpr, aliases map[string]string) (string, []string) {
 
+	// the type is shown with the name of its package and identified by the import path
 	packageName := v.X.(*ast.Ident).Name
-	if realPackageName, ok := aliases[packageName]; ok {
-		packageName = realPackageName
+	importPath := packageName
+	if path, ok := aliases[packageName]; ok {
+		packageName = packageNameOf(path)
+		importPath = path
 	}
-	t := fmt.Sprintf("%s.%s", packageName, v.Sel.Name)
-	return t, []string{t}
+	return fmt.Sprintf("%s.%s", packageName, v.Sel.Name), []string{fmt.Sprintf("%s.%s", importPath, v.Sel.Name)}
 }
 
 func getMapType(v *ast.MapType, aliases map[string]string) (string, []string) {
@@ -136,6 +156,48 @@ func getEllipsis(v *ast.Ellipsis, aliases map[string]string) (string, []string)
 	return fmt.Sprintf("...%s", t), []string{}
 }
 
+//getIndexExpr handles an instantiation with one type argument, e.g. List[int]. The generic type
+//and the type arguments are all fundamental types.
+func getIndexExpr(v *ast.IndexExpr, aliases map[string]string) (string, []string) {
+	t, f := getFieldType(v.X, aliases)
+	arg, fa := getFieldType(v.Index, aliases)
+	return fmt.Sprintf("%s[%s]", t, arg), append(f, fa...)
+}
+
+//getIndexListExpr handles an instantiation with several type arguments, e.g. Map[string, *Item]
+func getIndexListExpr(v *ast.IndexListExpr, aliases map[string]string) (string, []string) {
+	t, f := getFieldType(v.X, aliases)
+	args := make([]string, 0, len(v.Indices))
+	for _, index := range v.Indices {
+		arg, fa := getFieldType(index, aliases)
+		args = append(args, arg)
+		f = append(f, fa...)
+	}
+	return fmt.Sprintf("%s[%s]", t, strings.Join(args, ", ")), f
+}
+
+//getUnaryExpr handles the ~T terms of constraints
+func getUnaryExpr(v *ast.UnaryExpr, aliases map[string]string) (string, []string) {
+	t, f := getFieldType(v.X, aliases)
+	return fmt.Sprintf("%s%s", v.Op, t), f
+}
+
+//getBinaryExpr handles the unions of constraints, e.g. ~int | ~float64
+func getBinaryExpr(v *ast.BinaryExpr, aliases map[string]string) (string, []string) {
+	t1, f1 := getFieldType(v.X, aliases)
+	t2, f2 := getFieldType(v.Y, aliases)
+	return fmt.Sprintf("%s %s %s", t1, v.Op, t2), append(f1, f2...)
+}
+
+//getGenericType returns the generic type of an instantiation: Map for Map[string, int].
+//Other types are returned unchanged.
+func getGenericType(t string) string {
+	if i := strings.Index(t, "["); i > 0 {
+		return t[:i]
+	}
+	return t
+}
+
 var globalPrimitives = map[string]struct{}{
 	"bool":        {},
 	"string":      {},
@@ -157,6 +219,8 @@ var globalPrimitives = map[string]struct{}{
 	"complex64":   {},
 	"complex128":  {},
 	"error":       {},
+	"any":         {},
+	"comparable":  {},
 	"*bool":       {},
 	"*string":     {},
 	"*int":        {},
@@ -192,5 +256,5 @@ func replacePackageConstant(field, packageName string) string {
 	if packageName != "" {
 		packageName = fmt.Sprintf("%s.", packageName)
 	}
-	return strings.Replace(field, packageConstant, packageName, 1)
+	return strings.Replace(field, packageConstant, packageName, -1)
 }
@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"go/ast"
+	"go/token"
 )
 
 type NoMatchField struct {
@@ -254,6 +255,40 @@ func TestGetFieldType(t *testing.T) {
 				},
 			},
 		},
+		{
+			Name:                     "Test *ast.IndexExpr",
+			ExpectedResult:           fmt.Sprintf("%sList[int]", packageConstant),
+			ExpectedFundamentalTypes: []string{fmt.Sprintf("%sList", packageConstant)},
+			InputField: &ast.IndexExpr{
+				X:     &ast.Ident{Name: "List"},
+				Index: &ast.Ident{Name: "int"},
+			},
+		},
+		{
+			Name:           "Test *ast.IndexListExpr",
+			ExpectedResult: fmt.Sprintf("%sMap[string, *%sItem]", packageConstant, packageConstant),
+			ExpectedFundamentalTypes: []string{
+				fmt.Sprintf("%sMap", packageConstant),
+				fmt.Sprintf("%sItem", packageConstant),
+			},
+			InputField: &ast.IndexListExpr{
+				X: &ast.Ident{Name: "Map"},
+				Indices: []ast.Expr{
+					&ast.Ident{Name: "string"},
+					&ast.StarExpr{X: &ast.Ident{Name: "Item"}},
+				},
+			},
+		},
+		{
+			Name:                     "Test constraint union",
+			ExpectedResult:           "~int | ~float64",
+			ExpectedFundamentalTypes: []string{},
+			InputField: &ast.BinaryExpr{
+				X:  &ast.UnaryExpr{Op: token.TILDE, X: &ast.Ident{Name: "int"}},
+				Op: token.OR,
+				Y:  &ast.UnaryExpr{Op: token.TILDE, X: &ast.Ident{Name: "float64"}},
+			},
+		},
 	}
 	for _, tc := range tt {
 		t.Run(tc.Name, func(t *testing.T) {
@@ -277,3 +312,17 @@ func TestIsPrimitiveStringPointer(t *testing.T) {
 		t.Errorf("TestIsPrimitiveStringPointer: expecting true, got false")
 	}
 }
+
+func TestGetGenericType(t *testing.T) {
+	tt := map[string]string{
+		"Map[string, int]":    "Map",
+		"pkg.List[*pkg.Item]": "pkg.List",
+		"Plain":               "Plain",
+		"[]int":               "[]int",
+	}
+	for input, expected := range tt {
+		if result := getGenericType(input); result != expected {
+			t.Errorf("TestGetGenericType: expected %s for %s, got %s", expected, input, result)
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package gopuml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//compileTypeFilters compiles the regular expressions of the RenderInclude and RenderExclude options
+func compileTypeFilters(filters []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, filter := range filters {
+		reg, err := regexp.Compile(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile type filter %s: %v", filter, err)
+		}
+		result = append(result, reg)
+	}
+	return result, nil
+}
+
+//resolveType returns the package qualified name of a parsed type given as importpath.Type or
+//package.Type, e.g. nclient4.Client
+func (p *ClassParser) resolveType(focus string) (string, error) {
+	if p.getStruct(focus) != nil {
+		pack, name := p.splitName(focus)
+		return diagramID(pack, name), nil
+	}
+	var found []string
+	for _, pack := range p.parsedPackages() {
+		prefix := packageNameOf(pack) + "."
+		if !strings.HasPrefix(focus, prefix) {
+			continue
+		}
+		name := focus[len(prefix):]
+		if _, ok := p.structure[pack][name]; ok {
+			found = append(found, diagramID(pack, name))
+		} else if _, ok := p.structure[pack][pack+"."+name]; ok {
+			// defined types that are not structs or interfaces are kept under their qualified name
+			found = append(found, pack+"."+name)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return "", fmt.Errorf("failed to find the type %s", focus)
+	case 1:
+		return found[0], nil
+	}
+	return "", fmt.Errorf("ambiguous type %s: %s", focus, strings.Join(found, ", "))
+}
+
+//visibleTypes returns the types the diagram shows, the parsed types and the types they are related to,
+//after the include and exclude filters and the focus. It returns nil when every type is shown.
+func (p *ClassParser) visibleTypes() map[string]bool {
+	options := p.renderingOptions
+	if options.Focus == "" && len(options.Include) == 0 && len(options.Exclude) == 0 {
+		return nil
+	}
+	// the expressions were checked by SetRenderingOptions
+	include, _ := compileTypeFilters(options.Include)
+	exclude, _ := compileTypeFilters(options.Exclude)
+	allowed := func(id string) bool {
+		for _, reg := range exclude {
+			if reg.MatchString(id) {
+				return false
+			}
+		}
+		if len(include) == 0 {
+			return true
+		}
+		for _, reg := range include {
+			if reg.MatchString(id) {
+				return true
+			}
+		}
+		return false
+	}
+
+	relations := p.relations()
+	visible := make(map[string]bool)
+	if options.Focus == "" {
+		for _, pack := range p.parsedPackages() {
+			for name := range p.structure[pack] {
+				if id := diagramID(pack, name); allowed(id) {
+					visible[id] = true
+				}
+			}
+			if _, ok := p.utilities[pack]; ok && p.renderingOptions.Utilities {
+				if id := diagramID(pack, p.utilityName(pack)); allowed(id) {
+					visible[id] = true
+				}
+			}
+		}
+		for _, r := range relations {
+			for _, id := range []string{r.From, r.To} {
+				if allowed(id) {
+					visible[id] = true
+				}
+			}
+		}
+		return visible
+	}
+
+	// breadth first search from the focus, following the rendered relations in both directions
+	neighbors := make(map[string][]string)
+	for _, r := range relations {
+		if allowed(r.From) && allowed(r.To) {
+			neighbors[r.From] = append(neighbors[r.From], r.To)
+			neighbors[r.To] = append(neighbors[r.To], r.From)
+		}
+	}
+	if !allowed(options.Focus) {
+		return visible
+	}
+	visible[options.Focus] = true
+	current := []string{options.Focus}
+	for depth := 0; depth < options.FocusDepth && len(current) > 0; depth++ {
+		var next []string
+		for _, id := range current {
+			for _, neighbor := range neighbors[id] {
+				if !visible[neighbor] {
+					visible[neighbor] = true
+					next = append(next, neighbor)
+				}
+			}
+		}
+		current = next
+	}
+	return visible
+}
+
+//isVisible returns true if the type is shown with the visible types of the current rendering
+func isVisible(visible map[string]bool, ids ...string) bool {
+	if visible == nil {
+		return true
+	}
+	for _, id := range ids {
+		if !visible[id] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,132 @@
+package gopuml
+
+import (
+	"strings"
+	"testing"
+)
+
+var focusModule = map[string]string{
+	"go.mod": "module example.com/chain\n",
+	"chain.go": `package chain
+
+type A struct {
+	B
+}
+
+type B struct {
+	C
+}
+
+type C struct {
+	D *D
+}
+
+type D struct{}
+
+type Unrelated struct{}
+
+type Named = A
+`,
+	"other/other.go": "package other\n\ntype B struct{}\n",
+}
+
+func TestFocus(t *testing.T) {
+	dir := writeTestModule(t, focusModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestFocus: expected no error, got %s", err)
+	}
+	tt := []struct {
+		Options  map[RenderingOption]interface{}
+		Expected []string
+	}{
+		{
+			Options:  map[RenderingOption]interface{}{RenderFocus: "chain.B", RenderFocusDepth: 0},
+			Expected: []string{"example.com/chain.B"},
+		},
+		{
+			Options:  map[RenderingOption]interface{}{RenderFocus: "chain.B", RenderFocusDepth: 1},
+			Expected: []string{"example.com/chain.A", "example.com/chain.B", "example.com/chain.C"},
+		},
+		{
+			Options:  map[RenderingOption]interface{}{RenderFocus: "example.com/chain.B", RenderFocusDepth: 2},
+			Expected: []string{"example.com/chain.A", "example.com/chain.B", "example.com/chain.C", "example.com/chain.Named"},
+		},
+		{
+			Options:  map[RenderingOption]interface{}{RenderFocus: "chain.B", RenderFocusDepth: 2, RenderAggregations: true},
+			Expected: []string{"example.com/chain.A", "example.com/chain.B", "example.com/chain.C", "example.com/chain.D", "example.com/chain.Named"},
+		},
+		{
+			Options:  map[RenderingOption]interface{}{RenderFocus: "chain.B", RenderFocusDepth: 2, RenderExclude: []string{`\.A$`}},
+			Expected: []string{"example.com/chain.B", "example.com/chain.C"},
+		},
+		{
+			Options:  map[RenderingOption]interface{}{RenderFocus: "", RenderInclude: []string{`chain\.[BD]$`}},
+			Expected: []string{"example.com/chain.B", "example.com/chain.D"},
+		},
+	}
+	for _, tc := range tt {
+		parser.renderingOptions = &RenderingOptions{Fields: true, Methods: true, Compositions: true, Implementations: true, Aliases: true}
+		if err := parser.SetRenderingOptions(tc.Options); err != nil {
+			t.Fatalf("TestFocus: expected no error for %v, got %s", tc.Options, err)
+		}
+		packages, _ := parser.diagram()
+		var result []string
+		for _, pack := range packages {
+			for _, dt := range pack.Types {
+				result = append(result, dt.ID)
+			}
+		}
+		if strings.Join(result, " ") != strings.Join(tc.Expected, " ") {
+			t.Errorf("TestFocus: expected %v for %v, got %v", tc.Expected, tc.Options, result)
+		}
+	}
+
+	parser.renderingOptions = &RenderingOptions{Fields: true, Methods: true, Compositions: true, Implementations: true, Aliases: true}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderFocus: "chain.A", RenderFocusDepth: 1})
+	expected := `@startuml
+left to right direction
+namespace example.com.chain {
+    class A << (S,Aquamarine) >> {
+    }
+    class B << (S,Aquamarine) >> {
+    }
+    class example.com.chain.Named << (T, #FF7700) >>  {
+    }
+}
+"example.com.chain.B" *-- "example.com.chain.A"
+
+
+"example.com.chain.A" #.. "example.com.chain.Named"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestFocus: expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestFocusErrors(t *testing.T) {
+	dir := writeTestModule(t, focusModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestFocusErrors: expected no error, got %s", err)
+	}
+	for _, options := range []map[RenderingOption]interface{}{
+		{RenderFocus: "chain.Missing"},
+		{RenderFocus: "B"},
+		{RenderInclude: []string{"("}},
+		{RenderExclude: []string{"["}},
+	} {
+		if err := parser.SetRenderingOptions(options); err == nil {
+			t.Errorf("TestFocusErrors: expected an error for %v", options)
+		}
+	}
+	_, err = NewClassDiagramWithOptions(&ClassDiagramOptions{
+		Directories:      []string{dir},
+		Recursive:        true,
+		RenderingOptions: map[RenderingOption]interface{}{RenderFocus: "chain.Missing"},
+	})
+	if err == nil {
+		t.Errorf("TestFocusErrors: expected NewClassDiagramWithOptions to fail for an unknown focus")
+	}
+}
@@ -12,6 +12,8 @@ type Function struct {
 	ReturnValues         []string
 	PackageName          string
 	FullNameReturnValues []string
+	// Doc is the doc comment of the method
+	Doc string
 }
 
 //SignturesAreEqual Returns true if the two functions have the same signature (parameter names are not checked)
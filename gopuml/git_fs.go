@@ -0,0 +1,75 @@
+package gopuml
+
+import (
+	"archive/tar"
+	"bytes"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+)
+
+//NewGitFs returns an in memory file system with the files of a revision of the git repository holding dir.
+//The files are at the same absolute paths as in the working tree, so the directories of a diagram can be
+//parsed at an older revision, e.g. NewGitFs(".", "main").
+func NewGitFs(dir, revision string) (afero.Fs, error) {
+	top, err := git(dir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil, fmt.Errorf("failed to find the git repository of %s: %v", dir, err)
+	}
+	top = strings.TrimSpace(top)
+	archive, err := git(top, "archive", "--format=tar", revision)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the revision %s: %v", revision, err)
+	}
+	fs := afero.NewMemMapFs()
+	reader := tar.NewReader(strings.NewReader(archive))
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the archive of %s: %v", revision, err)
+		}
+		path := filepath.Join(top, filepath.FromSlash(header.Name))
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = fs.MkdirAll(path, 0755)
+		case tar.TypeReg:
+			err = writeGitFile(fs, path, reader)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to copy %s of %s: %v", header.Name, revision, err)
+		}
+	}
+	return fs, nil
+}
+
+func writeGitFile(fs afero.Fs, path string, content io.Reader) error {
+	if err := fs.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := fs.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(f, content)
+	return err
+}
+
+//git runs a git command in a directory and returns its output, with the error output in the error
+func git(dir string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.String(), nil
+}
@@ -0,0 +1,112 @@
+package gopuml
+
+import (
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/afero"
+	"golang.org/x/mod/modfile"
+)
+
+//packageNameOf returns the package name an import path is usually imported with: its last element,
+//skipping a major version element (go-coap/v3) and without a version suffix (yaml.v3)
+func packageNameOf(importPath string) string {
+	name := path.Base(importPath)
+	if isMajorVersion(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+	if i := strings.Index(name, "."); i > 0 {
+		name = name[:i]
+	}
+	return name
+}
+
+func isMajorVersion(element string) bool {
+	if len(element) < 2 || element[0] != 'v' {
+		return false
+	}
+	for _, c := range element[1:] {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+//importPath returns the import path of the package in dir, built from the module path in the go.mod
+//file of the nearest parent directory. Packages outside of a module are identified by their name.
+func (p *ClassParser) importPath(dir, packageName string) string {
+	dir = filepath.Clean(dir)
+	for current := dir; ; current = filepath.Dir(current) {
+		data, err := afero.ReadFile(p.fileSystem, filepath.Join(current, "go.mod"))
+		if err == nil {
+			modulePath := modfile.ModulePath(data)
+			if modulePath == "" {
+				break
+			}
+			rel, err := filepath.Rel(current, dir)
+			if err != nil {
+				break
+			}
+			if rel == "." {
+				return modulePath
+			}
+			return path.Join(modulePath, filepath.ToSlash(rel))
+		}
+		if filepath.Dir(current) == current {
+			break
+		}
+	}
+	return packageName
+}
+
+//splitName splits a package qualified name into the import path of the package and the name.
+//Parsed packages are matched first since the names of aliases can contain dots, e.g. pkg.[]other.Type.
+func (p *ClassParser) splitName(qualifiedName string) (string, string) {
+	if strings.HasPrefix(qualifiedName, builtinPackageName+".") {
+		return builtinPackageName, qualifiedName[len(builtinPackageName)+1:]
+	}
+	best := ""
+	for pack := range p.structure {
+		if len(pack) > len(best) && strings.HasPrefix(qualifiedName, pack+".") {
+			best = pack
+		}
+	}
+	if best != "" {
+		return best, qualifiedName[len(best)+1:]
+	}
+	i := strings.LastIndex(qualifiedName, ".")
+	if i < 0 {
+		return "", qualifiedName
+	}
+	return qualifiedName[:i], qualifiedName[i+1:]
+}
+
+//namespace returns the PlantUML namespace of a package. The namespace prefix is removed from the import
+//path and its elements are nested namespaces, e.g. github.com/larryr/tools/gopuml is rendered as
+//github.com.larryr.tools.gopuml, or gopuml with the github.com/larryr/tools prefix.
+func (p *ClassParser) namespace(importPath string) string {
+	return strings.Replace(p.shortPath(importPath), "/", ".", -1)
+}
+
+//shortPath returns the import path without the namespace prefix
+func (p *ClassParser) shortPath(importPath string) string {
+	if prefix := strings.TrimSuffix(p.renderingOptions.NamespacePrefix, "/"); prefix != "" {
+		if importPath == prefix {
+			importPath = path.Base(importPath)
+		} else {
+			importPath = strings.TrimPrefix(importPath, prefix+"/")
+		}
+	}
+	return importPath
+}
+
+//renderName returns the name a package qualified type has in the diagram
+func (p *ClassParser) renderName(qualifiedName string) string {
+	pack, name := p.splitName(qualifiedName)
+	if pack == "" {
+		return qualifiedName
+	}
+	return p.namespace(pack) + "." + name
+}
@@ -0,0 +1,136 @@
+package gopuml
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed themes/*.puml
+var themes embed.FS
+
+//DefaultTheme is the theme of the former default style, bundled so that no style is fetched when rendering
+const DefaultTheme = "default"
+
+//DirectionLeftToRight lays the diagrams out from left to right, the default value of the RenderDirection option
+const DirectionLeftToRight = "left to right"
+
+//DirectionTopToBottom lays the diagrams out from top to bottom
+const DirectionTopToBottom = "top to bottom"
+
+//groupings are the values of the RenderGrouping option besides the default namespaces: the PlantUML
+//package styles the packages are drawn with
+var groupings = map[string]string{
+	"package":   "",
+	"folder":    "<<Folder>>",
+	"frame":     "<<Frame>>",
+	"rectangle": "<<Rectangle>>",
+	"cloud":     "<<Cloud>>",
+	"node":      "<<Node>>",
+	"database":  "<<Database>>",
+}
+
+//Themes returns the names of the themes bundled with gopuml, sorted
+func Themes() []string {
+	entries, _ := themes.ReadDir("themes")
+	var names []string
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".puml"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+//theme returns the skinparams of a bundled theme
+func theme(name string) (string, error) {
+	content, err := themes.ReadFile("themes/" + name + ".puml")
+	if err != nil {
+		return "", fmt.Errorf("unknown theme %s, expected one of %s", name, strings.Join(Themes(), ", "))
+	}
+	return strings.TrimSpace(string(content)), nil
+}
+
+//renderStyle writes the theme and the style include of a PlantUML diagram
+func (p *ClassParser) renderStyle(str *LineStringBuilder) {
+	if p.renderingOptions.Theme != "" {
+		// the theme was checked by SetRenderingOptions
+		content, _ := theme(p.renderingOptions.Theme)
+		str.WriteLineWithDepth(0, content)
+	}
+	if p.renderingOptions.Style != "" {
+		str.WriteLineWithDepth(0, fmt.Sprintf(`!includeurl %s`, p.renderingOptions.Style))
+	}
+}
+
+//renderDirection writes the layout direction of a class or package diagram
+func (p *ClassParser) renderDirection(str *LineStringBuilder) {
+	direction := p.renderingOptions.Direction
+	if direction == "" {
+		direction = DirectionLeftToRight
+	}
+	str.WriteLineWithDepth(0, fmt.Sprintf("%s direction", direction))
+}
+
+//checkLayout returns an error if the direction or the grouping is not supported
+func checkLayout(direction, grouping string) error {
+	switch direction {
+	case "", DirectionLeftToRight, DirectionTopToBottom:
+	default:
+		return fmt.Errorf("unknown direction %s, expected %s or %s", direction, DirectionLeftToRight, DirectionTopToBottom)
+	}
+	if _, ok := groupings[grouping]; grouping != "" && grouping != "namespace" && !ok {
+		var names []string
+		for name := range groupings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("unknown grouping %s, expected namespace, %s", grouping, strings.Join(names, ", "))
+	}
+	return nil
+}
+
+//groupStart returns the line that opens the group of the types of a package
+func (p *ClassParser) groupStart(pack string) string {
+	style, ok := groupings[p.renderingOptions.Grouping]
+	if !ok {
+		return fmt.Sprintf(`namespace %s {`, p.namespace(pack))
+	}
+	if style == "" {
+		return fmt.Sprintf(`package %s {`, p.namespace(pack))
+	}
+	return fmt.Sprintf(`package %s %s {`, p.namespace(pack), style)
+}
+
+//resolveHiddenLinks returns the hidden links of the RenderHiddenLinks option, From->To, with the package
+//qualified names of their types
+func (p *ClassParser) resolveHiddenLinks(links []string) ([]string, error) {
+	var result []string
+	for _, link := range links {
+		from, to, ok := strings.Cut(link, "->")
+		if !ok {
+			return nil, fmt.Errorf("invalid hidden link %s, expected From->To", link)
+		}
+		fromID, err := p.resolveType(strings.TrimSpace(from))
+		if err != nil {
+			return nil, err
+		}
+		toID, err := p.resolveType(strings.TrimSpace(to))
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, fromID+"->"+toID)
+	}
+	return result, nil
+}
+
+//renderHiddenLinks writes the hidden links between visible types, which PlantUML lays out like connections
+//without drawing them, e.g. to place two unrelated types next to each other
+func (p *ClassParser) renderHiddenLinks(str *LineStringBuilder) {
+	for _, link := range p.renderingOptions.HiddenLinks {
+		from, to, _ := strings.Cut(link, "->")
+		if isVisible(p.visible, from, to) {
+			str.WriteLineWithDepth(0, fmt.Sprintf(`"%s" -[hidden]-> "%s"`, p.renderName(from), p.renderName(to)))
+		}
+	}
+}
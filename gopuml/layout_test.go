@@ -0,0 +1,283 @@
+package gopuml
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestThemes(t *testing.T) {
+	if result := Themes(); !reflect.DeepEqual(result, []string{"dark", "default", "plain"}) {
+		t.Errorf("TestThemes: expected the bundled themes, got %v", result)
+	}
+}
+
+func TestLayout(t *testing.T) {
+	dir := writeTestModule(t, focusModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestLayout: expected no error, got %s", err)
+	}
+	tt := []struct {
+		Options  map[RenderingOption]interface{}
+		Expected string
+	}{
+		{
+			Options: map[RenderingOption]interface{}{},
+			Expected: `@startuml
+left to right direction
+namespace example.com.chain {
+    class A << (S,Aquamarine) >> {
+    }
+    class B << (S,Aquamarine) >> {
+    }
+    class C << (S,Aquamarine) >> {
+        + D *D
+
+    }
+    class D << (S,Aquamarine) >> {
+    }
+    class Unrelated << (S,Aquamarine) >> {
+    }
+    class example.com.chain.Named << (T, #FF7700) >>  {
+    }
+}
+"example.com.chain.B" *-- "example.com.chain.A"
+"example.com.chain.C" *-- "example.com.chain.B"
+
+
+namespace example.com.chain.other {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+"example.com.chain.A" #.. "example.com.chain.Named"
+@enduml
+`,
+		},
+		{
+			Options: map[RenderingOption]interface{}{RenderTheme: DefaultTheme, RenderStyle: "https://example.com/style.puml"},
+			Expected: `@startuml
+' default: the gopuml class diagram style
+skinparam shadowing false
+skinparam defaultFontName Helvetica
+skinparam defaultFontSize 12
+skinparam roundCorner 8
+skinparam packageStyle rectangle
+skinparam ArrowColor #555555
+skinparam ArrowFontColor #555555
+skinparam class {
+    BackgroundColor #FEFEFE
+    BorderColor #4A6FA5
+    HeaderBackgroundColor #DCE6F2
+    AttributeFontColor #333333
+    StereotypeFontColor #4A6FA5
+}
+skinparam package {
+    BackgroundColor #F7F9FC
+    BorderColor #9DB2D0
+    FontColor #4A6FA5
+}
+skinparam note {
+    BackgroundColor #FFF8DC
+    BorderColor #C8B560
+}
+skinparam legend {
+    BackgroundColor #F5F5F5
+    BorderColor #AAAAAA
+}
+!includeurl https://example.com/style.puml
+left to right direction
+namespace example.com.chain {
+    class A << (S,Aquamarine) >> {
+    }
+    class B << (S,Aquamarine) >> {
+    }
+    class C << (S,Aquamarine) >> {
+        + D *D
+
+    }
+    class D << (S,Aquamarine) >> {
+    }
+    class Unrelated << (S,Aquamarine) >> {
+    }
+    class example.com.chain.Named << (T, #FF7700) >>  {
+    }
+}
+"example.com.chain.B" *-- "example.com.chain.A"
+"example.com.chain.C" *-- "example.com.chain.B"
+
+
+namespace example.com.chain.other {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+"example.com.chain.A" #.. "example.com.chain.Named"
+@enduml
+`,
+		},
+		{
+			Options: map[RenderingOption]interface{}{RenderDirection: DirectionTopToBottom, RenderGrouping: "folder"},
+			Expected: `@startuml
+top to bottom direction
+package example.com.chain <<Folder>> {
+    class A << (S,Aquamarine) >> {
+    }
+    class B << (S,Aquamarine) >> {
+    }
+    class C << (S,Aquamarine) >> {
+        + D *D
+
+    }
+    class D << (S,Aquamarine) >> {
+    }
+    class Unrelated << (S,Aquamarine) >> {
+    }
+    class example.com.chain.Named << (T, #FF7700) >>  {
+    }
+}
+"example.com.chain.B" *-- "example.com.chain.A"
+"example.com.chain.C" *-- "example.com.chain.B"
+
+
+package example.com.chain.other <<Folder>> {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+"example.com.chain.A" #.. "example.com.chain.Named"
+@enduml
+`,
+		},
+		{
+			Options: map[RenderingOption]interface{}{RenderGrouping: "package"},
+			Expected: `@startuml
+left to right direction
+package example.com.chain {
+    class A << (S,Aquamarine) >> {
+    }
+    class B << (S,Aquamarine) >> {
+    }
+    class C << (S,Aquamarine) >> {
+        + D *D
+
+    }
+    class D << (S,Aquamarine) >> {
+    }
+    class Unrelated << (S,Aquamarine) >> {
+    }
+    class example.com.chain.Named << (T, #FF7700) >>  {
+    }
+}
+"example.com.chain.B" *-- "example.com.chain.A"
+"example.com.chain.C" *-- "example.com.chain.B"
+
+
+package example.com.chain.other {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+"example.com.chain.A" #.. "example.com.chain.Named"
+@enduml
+`,
+		},
+		{
+			Options: map[RenderingOption]interface{}{RenderHiddenLinks: []string{"chain.Unrelated->chain.D", "example.com/chain.A -> chain.Named"}},
+			Expected: `@startuml
+left to right direction
+namespace example.com.chain {
+    class A << (S,Aquamarine) >> {
+    }
+    class B << (S,Aquamarine) >> {
+    }
+    class C << (S,Aquamarine) >> {
+        + D *D
+
+    }
+    class D << (S,Aquamarine) >> {
+    }
+    class Unrelated << (S,Aquamarine) >> {
+    }
+    class example.com.chain.Named << (T, #FF7700) >>  {
+    }
+}
+"example.com.chain.B" *-- "example.com.chain.A"
+"example.com.chain.C" *-- "example.com.chain.B"
+
+
+namespace example.com.chain.other {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+"example.com.chain.A" #.. "example.com.chain.Named"
+"example.com.chain.Unrelated" -[hidden]-> "example.com.chain.D"
+"example.com.chain.A" -[hidden]-> "example.com.chain.Named"
+@enduml
+`,
+		},
+		{
+			// hidden links to excluded types are left out
+			Options: map[RenderingOption]interface{}{RenderHiddenLinks: []string{"chain.Unrelated->chain.D"}, RenderExclude: []string{`\.D$`}},
+			Expected: `@startuml
+left to right direction
+namespace example.com.chain {
+    class A << (S,Aquamarine) >> {
+    }
+    class B << (S,Aquamarine) >> {
+    }
+    class C << (S,Aquamarine) >> {
+        + D *D
+
+    }
+    class Unrelated << (S,Aquamarine) >> {
+    }
+    class example.com.chain.Named << (T, #FF7700) >>  {
+    }
+}
+"example.com.chain.B" *-- "example.com.chain.A"
+"example.com.chain.C" *-- "example.com.chain.B"
+
+
+namespace example.com.chain.other {
+    class B << (S,Aquamarine) >> {
+    }
+}
+
+
+"example.com.chain.A" #.. "example.com.chain.Named"
+@enduml
+`,
+		},
+	}
+	for _, tc := range tt {
+		reset := map[RenderingOption]interface{}{RenderTheme: "", RenderStyle: "", RenderDirection: "", RenderGrouping: "", RenderHiddenLinks: []string(nil), RenderExclude: []string(nil)}
+		if err := parser.SetRenderingOptions(reset); err != nil {
+			t.Fatalf("TestLayout: expected no error, got %s", err)
+		}
+		if err := parser.SetRenderingOptions(tc.Options); err != nil {
+			t.Fatalf("TestLayout: expected no error for %v, got %s", tc.Options, err)
+		}
+		if result := parser.Render(); result != tc.Expected {
+			t.Errorf("TestLayout: expected with %v\n%s\ngot\n%s", tc.Options, tc.Expected, result)
+		}
+	}
+
+	for _, options := range []map[RenderingOption]interface{}{
+		{RenderTheme: "unknown"},
+		{RenderDirection: "bottom to top"},
+		{RenderGrouping: "box"},
+		{RenderHiddenLinks: []string{"chain.A"}},
+		{RenderHiddenLinks: []string{"chain.A->chain.Missing"}},
+	} {
+		if err := parser.SetRenderingOptions(options); err == nil {
+			t.Errorf("TestLayout: expected an error for %v", options)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package gopuml
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+//ModelVersion is the version of the JSON schema of Model. It changes when a field is removed or changes
+//meaning, not when one is added.
+const ModelVersion = 1
+
+//Model is the parsed model of a class diagram, written by the JSON renderer so that other tools can read
+//the types without parsing Go. Types are written in Go syntax and referred to by their package qualified
+//name, e.g. github.com/larryr/tools/gopuml.Struct.
+type Model struct {
+	Version  int             `json:"version"`
+	Packages []*ModelPackage `json:"packages"`
+	Aliases  []*ModelAlias   `json:"aliases,omitempty"`
+}
+
+//ModelPackage is a parsed package, by import path
+type ModelPackage struct {
+	Path string `json:"path"`
+	// Imports are the import paths the package imports
+	Imports []string     `json:"imports,omitempty"`
+	Types   []*ModelType `json:"types,omitempty"`
+	// Utility holds the package level functions, variables and constants
+	Utility *ModelType `json:"utility,omitempty"`
+	// Creates are the types the package level functions return
+	Creates []string `json:"creates,omitempty"`
+}
+
+//ModelType is a type of a package. Its kind is class for structs and the types methods are declared on,
+//interface, or alias for the other defined types. The relations hold package qualified names, except for
+//the predeclared types.
+type ModelType struct {
+	Name         string           `json:"name"`
+	Kind         string           `json:"kind"`
+	Doc          string           `json:"doc,omitempty"`
+	TypeParams   []*ModelField    `json:"typeParams,omitempty"`
+	TypeSet      []string         `json:"typeSet,omitempty"`
+	Fields       []*ModelField    `json:"fields,omitempty"`
+	Methods      []*ModelFunction `json:"methods,omitempty"`
+	Constants    []*ModelConstant `json:"constants,omitempty"`
+	Compositions []string         `json:"compositions,omitempty"`
+	Implements   []string         `json:"implements,omitempty"`
+	Aggregations []string         `json:"aggregations,omitempty"`
+	// PrivateAggregations are the aggregations of the private fields
+	PrivateAggregations []string `json:"privateAggregations,omitempty"`
+}
+
+//ModelField is a field, a parameter or a type parameter with its constraint as type
+type ModelField struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+	Tag  string `json:"tag,omitempty"`
+}
+
+//ModelFunction is a method, or a package level function of a utility
+type ModelFunction struct {
+	Name       string        `json:"name"`
+	Parameters []*ModelField `json:"parameters,omitempty"`
+	Results    []string      `json:"results,omitempty"`
+	Doc        string        `json:"doc,omitempty"`
+}
+
+//ModelConstant is a constant declared with the type, and its value when it is known
+type ModelConstant struct {
+	Name  string `json:"name"`
+	Value string `json:"value,omitempty"`
+}
+
+//ModelAlias is a defined type, Name, and the type it is defined as, Of. Of is package qualified unless it
+//is a predeclared type or a composite of one, e.g. int or map[string][]byte.
+type ModelAlias struct {
+	Name string `json:"name"`
+	Of   string `json:"of"`
+}
+
+//JSONRenderer writes the parsed model as JSON, the Model schema. Unlike the diagram renderers it ignores
+//the rendering options: the model is complete, and the options apply when it is loaded and rendered.
+type JSONRenderer struct{}
+
+//Render returns the JSON model of the parser
+func (JSONRenderer) Render(p *ClassParser) string {
+	// a model holds strings, maps and slices only, marshaling it cannot fail
+	result, _ := json.MarshalIndent(p.Model(), "", "  ")
+	return string(result) + "\n"
+}
+
+//goKeywords are the keywords the parser marks up in PlantUML. They are matched with the character that
+//follows them in a type, which an element of an import path is never followed by.
+var goKeywords = regexp.MustCompile(`\b(map|chan|struct|interface|func)([\[ {(])`)
+
+//packageQualified matches the types that start with an import path, the types of aliases that are not
+//predeclared ones
+var packageQualified = regexp.MustCompile(`^[\w./~-]+\.`)
+
+//goSyntax removes the PlantUML markup of a type
+func goSyntax(text string) string {
+	return plantUMLMarkup.ReplaceAllString(text, "$1")
+}
+
+//plantUMLSyntax marks the Go keywords of a type up like the parser does
+func plantUMLSyntax(text string) string {
+	return goKeywords.ReplaceAllString(text, "<font color=blue>$1</font>$2")
+}
+
+//Model returns the parsed types, sorted by name, with the members in their declaration order
+func (p *ClassParser) Model() *Model {
+	model := &Model{Version: ModelVersion, Packages: []*ModelPackage{}}
+	var packs []string
+	for pack := range p.structure {
+		packs = append(packs, pack)
+	}
+	sort.Strings(packs)
+	for _, pack := range packs {
+		mp := &ModelPackage{Path: pack, Imports: sortedKeys(p.packageImports[pack]), Creates: sortedKeys(p.creates[pack])}
+		var names []string
+		for name := range p.structure[pack] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			mt := modelType(p.structure[pack][name])
+			mt.Name = strings.TrimPrefix(name, pack+".")
+			for _, c := range p.constants[pack][name] {
+				mt.Constants = append(mt.Constants, &ModelConstant{Name: c.Name, Value: c.Value})
+			}
+			mp.Types = append(mp.Types, mt)
+		}
+		if utility, ok := p.utilities[pack]; ok {
+			mp.Utility = modelType(utility)
+		}
+		model.Packages = append(model.Packages, mp)
+	}
+	orderedAliases := AliasSlice{}
+	for _, alias := range p.allAliases {
+		orderedAliases = append(orderedAliases, *alias)
+	}
+	sort.Sort(orderedAliases)
+	for _, alias := range orderedAliases {
+		of := strings.TrimPrefix(goSyntax(alias.Name), builtinPackageName+".")
+		model.Aliases = append(model.Aliases, &ModelAlias{Name: alias.AliasOf, Of: of})
+	}
+	return model
+}
+
+func modelType(structure *Struct) *ModelType {
+	mt := &ModelType{
+		Kind:                structure.Type,
+		Doc:                 structure.Doc,
+		TypeParams:          modelFields(structure.TypeParams),
+		Fields:              modelFields(structure.Fields),
+		Compositions:        qualifiedNames(structure, structure.Composition),
+		Implements:          qualifiedNames(structure, structure.Extends),
+		Aggregations:        qualifiedNames(structure, structure.Aggregations),
+		PrivateAggregations: qualifiedNames(structure, structure.PrivateAggregations),
+	}
+	for _, element := range structure.TypeSet {
+		mt.TypeSet = append(mt.TypeSet, goSyntax(element))
+	}
+	for _, f := range structure.Functions {
+		mt.Methods = append(mt.Methods, &ModelFunction{
+			Name:       f.Name,
+			Parameters: modelFields(f.Parameters),
+			Results:    goSyntaxes(f.ReturnValues),
+			Doc:        f.Doc,
+		})
+	}
+	return mt
+}
+
+//qualifiedNames returns the types a structure refers to with the import path of their package, sorted. The
+//predeclared types, e.g. error, have none.
+func qualifiedNames(structure *Struct, names map[string]struct{}) []string {
+	var result []string
+	for _, name := range sortedKeys(names) {
+		if !strings.Contains(name, ".") && !isPrimitiveString(name) {
+			name = fmt.Sprintf("%s.%s", structure.PackageName, name)
+		}
+		result = append(result, name)
+	}
+	return result
+}
+
+func modelFields(fields []*Field) []*ModelField {
+	var result []*ModelField
+	for _, f := range fields {
+		result = append(result, &ModelField{Name: f.Name, Type: goSyntax(f.Type), Tag: f.Tag})
+	}
+	return result
+}
+
+func goSyntaxes(types []string) []string {
+	var result []string
+	for _, t := range types {
+		result = append(result, goSyntax(t))
+	}
+	return result
+}
+
+//ReadModel reads a JSON model written by the JSON renderer
+func ReadModel(reader io.Reader) (*Model, error) {
+	model := &Model{}
+	if err := json.NewDecoder(reader).Decode(model); err != nil {
+		return nil, fmt.Errorf("failed to read the model: %v", err)
+	}
+	if model.Version != ModelVersion {
+		return nil, fmt.Errorf("unsupported model version %d, expected %d", model.Version, ModelVersion)
+	}
+	return model, nil
+}
+
+//NewClassDiagramFromModel returns a ClassParser that renders the types of a model, e.g. one written by
+//another gopuml run with the JSON renderer. Sequence diagrams need the parsed code and are not supported.
+func NewClassDiagramFromModel(model *Model, renderingOptions map[RenderingOption]interface{}) (*ClassParser, error) {
+	p := newClassParser()
+	for _, mp := range model.Packages {
+		p.structure[mp.Path] = make(map[string]*Struct)
+		if len(mp.Imports) > 0 {
+			p.packageImports[mp.Path] = setOf(mp.Imports)
+		}
+		if len(mp.Creates) > 0 {
+			p.creates[mp.Path] = setOf(mp.Creates)
+		}
+		for _, mt := range mp.Types {
+			name := mt.Name
+			if mt.Kind == "alias" && !isPrimitiveString(name) {
+				// named types that are not structs or interfaces are kept under their qualified name
+				name = fmt.Sprintf("%s.%s", mp.Path, name)
+			}
+			p.structure[mp.Path][name] = structOf(mp.Path, mt)
+			for _, c := range mt.Constants {
+				if _, ok := p.constants[mp.Path]; !ok {
+					p.constants[mp.Path] = make(map[string][]*Constant)
+				}
+				p.constants[mp.Path][name] = append(p.constants[mp.Path][name], &Constant{Name: c.Name, Value: c.Value})
+			}
+		}
+		if mp.Utility != nil {
+			p.utilities[mp.Path] = structOf(mp.Path, mp.Utility)
+		}
+	}
+	for _, alias := range model.Aliases {
+		pack, _ := p.splitName(alias.Name)
+		of := alias.Of
+		if !packageQualified.MatchString(of) {
+			of = fmt.Sprintf("%s.%s", builtinPackageName, of)
+		}
+		p.addAlias(alias.Name, &Alias{Name: plantUMLSyntax(of), PackageName: pack, AliasOf: alias.Name})
+	}
+	if err := p.SetRenderingOptions(renderingOptions); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+//structOf returns the structure of a type of a model. The full types the parser compares signatures with
+//are not part of the model, the types are.
+func structOf(pack string, mt *ModelType) *Struct {
+	structure := &Struct{
+		PackageName:         pack,
+		Functions:           make([]*Function, 0),
+		Fields:              parsedFields(mt.Fields),
+		Type:                mt.Kind,
+		Composition:         setOf(mt.Compositions),
+		Extends:             setOf(mt.Implements),
+		Aggregations:        setOf(mt.Aggregations),
+		PrivateAggregations: setOf(mt.PrivateAggregations),
+		Doc:                 mt.Doc,
+	}
+	if len(mt.TypeParams) > 0 {
+		structure.TypeParams = parsedFields(mt.TypeParams)
+	}
+	for _, element := range mt.TypeSet {
+		structure.TypeSet = append(structure.TypeSet, plantUMLSyntax(element))
+	}
+	for _, f := range mt.Methods {
+		results := make([]string, 0, len(f.Results))
+		for _, r := range f.Results {
+			results = append(results, plantUMLSyntax(r))
+		}
+		structure.Functions = append(structure.Functions, &Function{
+			Name:                 f.Name,
+			Parameters:           parsedFields(f.Parameters),
+			ReturnValues:         results,
+			PackageName:          pack,
+			FullNameReturnValues: results,
+			Doc:                  f.Doc,
+		})
+	}
+	return structure
+}
+
+func parsedFields(fields []*ModelField) []*Field {
+	result := make([]*Field, 0, len(fields))
+	for _, f := range fields {
+		t := plantUMLSyntax(f.Type)
+		result = append(result, &Field{Name: f.Name, Type: t, FullType: t, Tag: f.Tag})
+	}
+	return result
+}
+
+func setOf(names []string) map[string]struct{} {
+	result := make(map[string]struct{}, len(names))
+	for _, name := range names {
+		result[name] = struct{}{}
+	}
+	return result
+}
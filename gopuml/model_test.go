@@ -0,0 +1,273 @@
+package gopuml
+
+import (
+	"strings"
+	"testing"
+)
+
+var modelModule = map[string]string{
+	"go.mod": "module example.com/shop\n",
+	"shop.go": `package shop
+
+import "io"
+
+// Store keeps the orders.
+type Store interface {
+	Get(id string) (*Order, error)
+}
+
+type Order struct {
+	ID     string ` + "`json:\"id\"`" + `
+	Lines  map[string]int
+	Notify chan struct{}
+	io.Writer
+	store  Store
+}
+
+func (o *Order) Get(id string) (*Order, error) { return o, nil }
+
+type State int
+
+const (
+	Open State = iota
+	Closed
+)
+
+type Set[T comparable] map[T]struct{}
+
+type Blobs map[string][]byte
+
+var Default = &Order{}
+
+func NewOrder(id string) *Order { return &Order{ID: id} }
+`,
+}
+
+func TestModel(t *testing.T) {
+	dir := writeTestModule(t, modelModule)
+	options := map[RenderingOption]interface{}{
+		RenderAggregations:      true,
+		AggregatePrivateMembers: true,
+		RenderConnectionLabels:  true,
+		RenderTags:              true,
+		RenderEnums:             true,
+		RenderUtilities:         true,
+		RenderDocs:              "full",
+	}
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{Directories: []string{dir}, RenderingOptions: options})
+	if err != nil {
+		t.Fatalf("TestModel: expected no error, got %s", err)
+	}
+	renderer, _ := NewRenderer(FormatJSON)
+	exported := renderer.Render(parser)
+	// the types are written in Go syntax, the predeclared ones without a package
+	expected := `{
+  "version": 1,
+  "packages": [
+    {
+      "path": "example.com/shop",
+      "imports": [
+        "io"
+      ],
+      "types": [
+        {
+          "name": "Order",
+          "kind": "class",
+          "fields": [
+            {
+              "name": "ID",
+              "type": "string",
+              "tag": "json:\"id\""
+            },
+            {
+              "name": "Lines",
+              "type": "map[string]int"
+            },
+            {
+              "name": "Notify",
+              "type": "chan struct{}"
+            },
+            {
+              "name": "store",
+              "type": "Store"
+            }
+          ],
+          "methods": [
+            {
+              "name": "Get",
+              "parameters": [
+                {
+                  "name": "id",
+                  "type": "string"
+                }
+              ],
+              "results": [
+                "*Order",
+                "error"
+              ]
+            }
+          ],
+          "compositions": [
+            "io.Writer"
+          ],
+          "implements": [
+            "example.com/shop.Store"
+          ],
+          "privateAggregations": [
+            "example.com/shop.Store"
+          ]
+        },
+        {
+          "name": "Store",
+          "kind": "interface",
+          "doc": "Store keeps the orders.",
+          "methods": [
+            {
+              "name": "Get",
+              "parameters": [
+                {
+                  "name": "id",
+                  "type": "string"
+                }
+              ],
+              "results": [
+                "*Order",
+                "error"
+              ]
+            }
+          ]
+        },
+        {
+          "name": "Blobs",
+          "kind": "alias"
+        },
+        {
+          "name": "Set",
+          "kind": "alias",
+          "typeParams": [
+            {
+              "name": "T",
+              "type": "comparable"
+            }
+          ]
+        },
+        {
+          "name": "State",
+          "kind": "alias",
+          "constants": [
+            {
+              "name": "Open",
+              "value": "0"
+            },
+            {
+              "name": "Closed",
+              "value": "1"
+            }
+          ]
+        }
+      ],
+      "utility": {
+        "name": "",
+        "kind": "utility",
+        "fields": [
+          {
+            "name": "Open",
+            "type": "State = 0"
+          },
+          {
+            "name": "Closed",
+            "type": "State = 1"
+          },
+          {
+            "name": "Default",
+            "type": "*Order"
+          }
+        ],
+        "methods": [
+          {
+            "name": "NewOrder",
+            "parameters": [
+              {
+                "name": "id",
+                "type": "string"
+              }
+            ],
+            "results": [
+              "*Order"
+            ]
+          }
+        ]
+      },
+      "creates": [
+        "example.com/shop.Order"
+      ]
+    }
+  ],
+  "aliases": [
+    {
+      "name": "example.com/shop.Set",
+      "of": "map[T]struct{}"
+    },
+    {
+      "name": "example.com/shop.Blobs",
+      "of": "map[string][]byte"
+    },
+    {
+      "name": "example.com/shop.State",
+      "of": "int"
+    }
+  ]
+}
+`
+	if exported != expected {
+		t.Errorf("TestModel: expected\n%s\ngot\n%s", expected, exported)
+	}
+
+	model, err := ReadModel(strings.NewReader(exported))
+	if err != nil {
+		t.Fatalf("TestModel: expected no error, got %s", err)
+	}
+	loaded, err := NewClassDiagramFromModel(model, options)
+	if err != nil {
+		t.Fatalf("TestModel: expected no error, got %s", err)
+	}
+	for _, format := range []string{FormatPlantUML, FormatMermaid, FormatDOT, FormatD2, FormatJSON} {
+		renderer, _ := NewRenderer(format)
+		if expected, got := renderer.Render(parser), renderer.Render(loaded); got != expected {
+			t.Errorf("TestModel: expected the loaded model to render in %s like the parsed code\n%s\ngot\n%s", format, expected, got)
+		}
+	}
+	if expected, got := parser.RenderPackages(), loaded.RenderPackages(); got != expected {
+		t.Errorf("TestModel: expected the loaded model to render the packages like the parsed code\n%s\ngot\n%s", expected, got)
+	}
+}
+
+func TestReadModel(t *testing.T) {
+	if _, err := ReadModel(strings.NewReader(`{"version": 2, "packages": []}`)); err == nil {
+		t.Errorf("TestReadModel: expected an error for an unsupported version")
+	}
+	if _, err := ReadModel(strings.NewReader(`{"version": `)); err == nil {
+		t.Errorf("TestReadModel: expected an error for invalid JSON")
+	}
+	model, err := ReadModel(strings.NewReader(`{"version": 1, "packages": [{"path": "example.com/a", "types": [{"name": "A", "kind": "class"}]}]}`))
+	if err != nil {
+		t.Fatalf("TestReadModel: expected no error, got %s", err)
+	}
+	parser, err := NewClassDiagramFromModel(model, nil)
+	if err != nil {
+		t.Fatalf("TestReadModel: expected no error, got %s", err)
+	}
+	expected := `@startuml
+left to right direction
+namespace example.com.a {
+    class A << (S,Aquamarine) >> {
+    }
+}
+
+
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestReadModel: expected\n%s\ngot\n%s", expected, result)
+	}
+}
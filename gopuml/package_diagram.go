@@ -0,0 +1,182 @@
+package gopuml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//addPackageImport records that the current package imports importPath
+func (p *ClassParser) addPackageImport(importPath string) {
+	imports, ok := p.packageImports[p.currentPackageName]
+	if !ok {
+		imports = make(map[string]struct{})
+		p.packageImports[p.currentPackageName] = imports
+	}
+	imports[importPath] = struct{}{}
+}
+
+//parsedPackages returns the sorted import paths of the parsed packages
+func (p *ClassParser) parsedPackages() []string {
+	var packages []string
+	for pack := range p.structure {
+		if pack != builtinPackageName {
+			packages = append(packages, pack)
+		}
+	}
+	sort.Strings(packages)
+	return packages
+}
+
+//packageGraph returns the imports between the parsed packages, and the imported packages that were not
+//parsed, sorted by import path
+func (p *ClassParser) packageGraph() (map[string][]string, []string) {
+	parsed := make(map[string]bool)
+	for _, pack := range p.parsedPackages() {
+		parsed[pack] = true
+	}
+	graph := make(map[string][]string)
+	external := make(map[string]struct{})
+	for pack := range parsed {
+		for importPath := range p.packageImports[pack] {
+			if parsed[importPath] {
+				graph[pack] = append(graph[pack], importPath)
+			} else {
+				external[importPath] = struct{}{}
+			}
+		}
+		sort.Strings(graph[pack])
+	}
+	var externals []string
+	for importPath := range external {
+		externals = append(externals, importPath)
+	}
+	sort.Strings(externals)
+	return graph, externals
+}
+
+//PackageCycles returns the groups of parsed packages that import each other, directly or through other
+//packages. Each group and the list of groups are sorted.
+func (p *ClassParser) PackageCycles() [][]string {
+	graph, _ := p.packageGraph()
+	var cycles [][]string
+	for _, component := range stronglyConnected(p.parsedPackages(), graph) {
+		if len(component) > 1 {
+			sort.Strings(component)
+			cycles = append(cycles, component)
+		}
+	}
+	sort.Slice(cycles, func(i, j int) bool { return cycles[i][0] < cycles[j][0] })
+	return cycles
+}
+
+//stronglyConnected returns the strongly connected components of the graph with Tarjan's algorithm
+func stronglyConnected(nodes []string, graph map[string][]string) [][]string {
+	index := make(map[string]int)
+	lowLink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var components [][]string
+	var visit func(node string)
+	visit = func(node string) {
+		index[node] = len(index)
+		lowLink[node] = index[node]
+		stack = append(stack, node)
+		onStack[node] = true
+		for _, next := range graph[node] {
+			if _, ok := index[next]; !ok {
+				visit(next)
+				if lowLink[next] < lowLink[node] {
+					lowLink[node] = lowLink[next]
+				}
+			} else if onStack[next] && index[next] < lowLink[node] {
+				lowLink[node] = index[next]
+			}
+		}
+		if lowLink[node] != index[node] {
+			return
+		}
+		var component []string
+		for {
+			last := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			onStack[last] = false
+			component = append(component, last)
+			if last == node {
+				break
+			}
+		}
+		components = append(components, component)
+	}
+	for _, node := range nodes {
+		if _, ok := index[node]; !ok {
+			visit(node)
+		}
+	}
+	return components
+}
+
+//RenderPackages returns a string of the component diagram of the imports between the parsed packages.
+//Imported packages that were not parsed are rendered with the RenderExternalPackages option, and the
+//packages and imports of import cycles are highlighted with the RenderPackageCycles option.
+func (p *ClassParser) RenderPackages() string {
+	str := &LineStringBuilder{}
+	str.WriteLineWithDepth(0, "@startuml")
+	p.renderStyle(str)
+	if p.renderingOptions.Title != "" {
+		str.WriteLineWithDepth(0, fmt.Sprintf(`title %s`, p.renderingOptions.Title))
+	}
+	p.renderDirection(str)
+	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
+		str.WriteLineWithDepth(0, "legend")
+		str.WriteLineWithDepth(0, note)
+		str.WriteLineWithDepth(0, "end legend")
+	}
+
+	graph, externals := p.packageGraph()
+	inCycle := make(map[string]int)
+	if p.renderingOptions.PackageCycles {
+		for i, cycle := range p.PackageCycles() {
+			for _, pack := range cycle {
+				inCycle[pack] = i + 1
+			}
+		}
+	}
+	packages := p.parsedPackages()
+	// aliases are numbered since import paths can only be told apart with their punctuation
+	aliases := make(map[string]string)
+	for _, importPath := range append(packages, externals...) {
+		aliases[importPath] = fmt.Sprintf("p%d", len(aliases)+1)
+	}
+	for _, pack := range packages {
+		color := ""
+		if inCycle[pack] > 0 {
+			color = " #pink"
+		}
+		str.WriteLineWithDepth(0, fmt.Sprintf(`component "%s" as %s%s`, p.shortPath(pack), aliases[pack], color))
+	}
+	if p.renderingOptions.ExternalPackages {
+		for _, importPath := range externals {
+			str.WriteLineWithDepth(0, fmt.Sprintf(`component "%s" as %s <<external>>`, importPath, aliases[importPath]))
+		}
+	}
+	for _, pack := range packages {
+		imports := graph[pack]
+		if p.renderingOptions.ExternalPackages {
+			imports = nil
+			for importPath := range p.packageImports[pack] {
+				imports = append(imports, importPath)
+			}
+			sort.Strings(imports)
+		}
+		for _, importPath := range imports {
+			arrow := "-->"
+			if inCycle[pack] > 0 && inCycle[pack] == inCycle[importPath] {
+				arrow = "-[#red]->"
+			}
+			str.WriteLineWithDepth(0, fmt.Sprintf("%s %s %s", aliases[pack], arrow, aliases[importPath]))
+		}
+	}
+	str.WriteLineWithDepth(0, "@enduml")
+	return str.String()
+}
@@ -0,0 +1,64 @@
+package gopuml
+
+import (
+	"reflect"
+	"testing"
+)
+
+var importCycleModule = map[string]string{
+	"go.mod":   "module example.com/cycle\n",
+	"a/a.go":   "package a\n\nimport \"example.com/cycle/b\"\n\nvar _ = b.B\n",
+	"b/b.go":   "package b\n\nimport _ \"example.com/cycle/a\"\n\nconst B = 1\n",
+	"cmd/c.go": "package main\n\nimport (\n\t\"fmt\"\n\n\t\"example.com/cycle/a\"\n)\n\nfunc main() { fmt.Println(a.X) }\n",
+}
+
+func TestPackageCycles(t *testing.T) {
+	dir := writeTestModule(t, importCycleModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestPackageCycles: expected no error, got %s", err)
+	}
+	expected := [][]string{{"example.com/cycle/a", "example.com/cycle/b"}}
+	if cycles := parser.PackageCycles(); !reflect.DeepEqual(cycles, expected) {
+		t.Errorf("TestPackageCycles: expected %v, got %v", expected, cycles)
+	}
+}
+
+func TestRenderPackages(t *testing.T) {
+	dir := writeTestModule(t, importCycleModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestRenderPackages: expected no error, got %s", err)
+	}
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderNamespacePrefix: "example.com/cycle"})
+	expected := `@startuml
+left to right direction
+component "a" as p1
+component "b" as p2
+component "cmd" as p3
+p1 --> p2
+p2 --> p1
+p3 --> p1
+@enduml
+`
+	if result := parser.RenderPackages(); result != expected {
+		t.Errorf("TestRenderPackages: expected\n%s\ngot\n%s", expected, result)
+	}
+
+	parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderExternalPackages: true, RenderPackageCycles: true})
+	expected = `@startuml
+left to right direction
+component "a" as p1 #pink
+component "b" as p2 #pink
+component "cmd" as p3
+component "fmt" as p4 <<external>>
+p1 -[#red]-> p2
+p2 -[#red]-> p1
+p3 --> p1
+p3 --> p4
+@enduml
+`
+	if result := parser.RenderPackages(); result != expected {
+		t.Errorf("TestRenderPackages: expected\n%s\ngot\n%s", expected, result)
+	}
+}
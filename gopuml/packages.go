@@ -0,0 +1,196 @@
+package gopuml
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+const loadMode = packages.NeedName | packages.NeedFiles | packages.NeedCompiledGoFiles | packages.NeedImports |
+	packages.NeedDeps | packages.NeedSyntax | packages.NeedTypes | packages.NeedTypesInfo
+
+//parsePackages loads the directories of the options with go/packages. Declarations are read from the
+//syntax trees like in the AST mode, while the implementations, the targets of aliases and the names of
+//imported packages come from the type checker.
+func (p *ClassParser) parsePackages(options *ClassDiagramOptions) error {
+	p.usedTypes = make(map[*types.TypeName]struct{})
+	for _, dir := range options.Directories {
+		pattern := "."
+		if options.Recursive {
+			pattern = "./..."
+		}
+		pkgs, err := packages.Load(packagesConfig(options, dir), pattern)
+		if err != nil {
+			return fmt.Errorf("failed to load packages in %s: %v", dir, err)
+		}
+		for _, pkg := range pkgs {
+			if len(pkg.Errors) > 0 {
+				return fmt.Errorf("failed to load package %s: %v", pkg.PkgPath, pkg.Errors[0])
+			}
+			if len(pkg.CompiledGoFiles) == 0 || isIgnored(filepath.Dir(pkg.CompiledGoFiles[0]), options.IgnoredDirectories) {
+				continue
+			}
+			p.parseTypedPackage(pkg)
+			p.loadedPackages = append(p.loadedPackages, pkg)
+		}
+	}
+	p.typeCheckedImplementations()
+	return nil
+}
+
+//packagesConfig returns the go/packages configuration for loading dir with the build tags and
+//platform of the options
+func packagesConfig(options *ClassDiagramOptions, dir string) *packages.Config {
+	cfg := &packages.Config{Mode: loadMode, Dir: dir}
+	if len(options.BuildTags) > 0 {
+		cfg.BuildFlags = []string{"-tags=" + strings.Join(options.BuildTags, ",")}
+	}
+	if options.GOOS != "" || options.GOARCH != "" {
+		cfg.Env = os.Environ()
+		if options.GOOS != "" {
+			cfg.Env = append(cfg.Env, "GOOS="+options.GOOS)
+		}
+		if options.GOARCH != "" {
+			cfg.Env = append(cfg.Env, "GOARCH="+options.GOARCH)
+		}
+	}
+	return cfg
+}
+
+//isIgnored returns true if dir is one of the ignored directories or below one
+func isIgnored(dir string, ignored []string) bool {
+	for _, i := range ignored {
+		if dir == i || strings.HasPrefix(dir, i+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+//parseTypedPackage parses the files of a loaded package in the order of their names
+func (p *ClassParser) parseTypedPackage(pkg *packages.Package) {
+	p.currentPackageName = pkg.PkgPath
+	if _, ok := p.structure[p.currentPackageName]; !ok {
+		p.structure[p.currentPackageName] = make(map[string]*Struct)
+	}
+	p.typeInfo = pkg.TypesInfo
+	for importPath := range pkg.Imports {
+		p.addPackageImport(importPath)
+	}
+	defer func() { p.typeInfo = nil }()
+
+	files := make(map[string]*ast.File)
+	var names []string
+	for _, f := range pkg.Syntax {
+		name := pkg.Fset.Position(f.Pos()).Filename
+		files[name] = f
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		f := files[name]
+		for _, impt := range f.Imports {
+			p.parseTypedImport(impt)
+		}
+		p.parseFile(f)
+	}
+	for _, obj := range pkg.TypesInfo.Uses {
+		if typeName, ok := obj.(*types.TypeName); ok && typeName.Pkg() != nil && typeName.Pkg() != pkg.Types {
+			p.usedTypes[typeName] = struct{}{}
+		}
+	}
+	p.typedPackages = append(p.typedPackages, pkg.Types)
+}
+
+//parseTypedImport records the import path of an imported package under the name it is used with
+func (p *ClassParser) parseTypedImport(impt *ast.ImportSpec) {
+	var obj types.Object
+	if impt.Name != nil {
+		obj = p.typeInfo.Defs[impt.Name]
+	} else {
+		obj = p.typeInfo.Implicits[impt]
+	}
+	if pkgName, ok := obj.(*types.PkgName); ok && pkgName.Name() != "_" && pkgName.Name() != "." {
+		p.allImports[pkgName.Name()] = pkgName.Imported().Path()
+	}
+}
+
+//resolvedTypeName returns the package qualified name of the named type the expression stands for,
+//e.g. the target of an alias, or an empty string when no types are known or it is not a named type
+func (p *ClassParser) resolvedTypeName(expr ast.Expr) string {
+	if p.typeInfo == nil {
+		return ""
+	}
+	tv, ok := p.typeInfo.Types[expr]
+	if !ok {
+		return ""
+	}
+	named, ok := types.Unalias(tv.Type).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil {
+		return ""
+	}
+	obj := named.Origin().Obj()
+	return fmt.Sprintf("%s.%s", obj.Pkg().Path(), obj.Name())
+}
+
+//typeCheckedImplementations adds an extends relation from every named type of the loaded packages to
+//each interface it implements, with a value or a pointer receiver. Besides the interfaces of the loaded
+//packages, the interfaces they use from other packages are checked, e.g. io.Reader.
+func (p *ClassParser) typeCheckedImplementations() {
+	loaded := make(map[*types.Package]bool)
+	for _, pkg := range p.typedPackages {
+		loaded[pkg] = true
+	}
+	interfaces := make(map[string]*types.Interface)
+	var concrete []*types.TypeName
+	addInterface := func(obj *types.TypeName) {
+		if obj.IsAlias() || obj.Pkg() == nil {
+			return
+		}
+		iface, ok := obj.Type().Underlying().(*types.Interface)
+		if !ok || iface.NumMethods() == 0 || !iface.IsMethodSet() {
+			return
+		}
+		if named, ok := obj.Type().(*types.Named); ok && named.TypeParams().Len() > 0 {
+			return
+		}
+		interfaces[fmt.Sprintf("%s.%s", obj.Pkg().Path(), obj.Name())] = iface
+	}
+	for _, pkg := range p.typedPackages {
+		scope := pkg.Scope()
+		for _, name := range scope.Names() {
+			obj, ok := scope.Lookup(name).(*types.TypeName)
+			if !ok {
+				continue
+			}
+			if types.IsInterface(obj.Type()) {
+				addInterface(obj)
+			} else if named, ok := obj.Type().(*types.Named); ok && !obj.IsAlias() && named.TypeParams().Len() == 0 {
+				concrete = append(concrete, obj)
+			}
+		}
+	}
+	for obj := range p.usedTypes {
+		if !loaded[obj.Pkg()] && obj.Exported() {
+			addInterface(obj)
+		}
+	}
+
+	for _, obj := range concrete {
+		st := p.structure[obj.Pkg().Path()][obj.Name()]
+		if st == nil {
+			continue
+		}
+		for name, iface := range interfaces {
+			if types.Implements(obj.Type(), iface) || types.Implements(types.NewPointer(obj.Type()), iface) {
+				st.AddToExtends(name)
+			}
+		}
+	}
+}
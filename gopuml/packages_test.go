@@ -0,0 +1,137 @@
+package gopuml
+
+import (
+	"strings"
+	"testing"
+)
+
+var typeCheckedModule = map[string]string{
+	"go.mod": "module example.com/shapes\n\ngo 1.21\n",
+	"shapes.go": `package shapes
+
+import (
+	"io"
+	str "strings"
+)
+
+type Closer interface {
+	Close() error
+}
+
+type Base struct{}
+
+func (b *Base) Read(p []byte) (int, error) { return 0, nil }
+
+// Wrapped only has the promoted Read method of Base
+type Wrapped struct {
+	*Base
+}
+
+type Value struct{}
+
+func (Value) Close() error { return nil }
+
+type Pointer struct{}
+
+func (*Pointer) Close() error { return nil }
+
+type Builder = str.Builder
+
+func Copy(r io.Reader) {}
+`,
+}
+
+func TestTypeCheckedImplementations(t *testing.T) {
+	dir := writeTestModule(t, typeCheckedModule)
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{
+		Directories:      []string{dir},
+		TypeChecked:      true,
+		RenderingOptions: map[RenderingOption]interface{}{},
+	})
+	if err != nil {
+		t.Fatalf("TestTypeCheckedImplementations: expected no error, got %s", err)
+	}
+	tt := []struct {
+		Name       string
+		Interface  string
+		Implements bool
+	}{
+		{Name: "example.com/shapes.Base", Interface: "io.Reader", Implements: true},
+		{Name: "example.com/shapes.Wrapped", Interface: "io.Reader", Implements: true},
+		{Name: "example.com/shapes.Value", Interface: "example.com/shapes.Closer", Implements: true},
+		{Name: "example.com/shapes.Pointer", Interface: "example.com/shapes.Closer", Implements: true},
+		{Name: "example.com/shapes.Base", Interface: "example.com/shapes.Closer", Implements: false},
+	}
+	for _, tc := range tt {
+		st := parser.getStruct(tc.Name)
+		if st == nil {
+			t.Errorf("TestTypeCheckedImplementations: expected %s to exist", tc.Name)
+			continue
+		}
+		if _, ok := st.Extends[tc.Interface]; ok != tc.Implements {
+			t.Errorf("TestTypeCheckedImplementations: expected %s implements %s to be %t, got extends %v", tc.Name, tc.Interface, tc.Implements, st.Extends)
+		}
+	}
+	alias := parser.allAliases["example.com/shapes.Builder"]
+	if alias == nil || alias.Name != "strings.Builder" {
+		t.Errorf("TestTypeCheckedImplementations: expected Builder to be an alias of strings.Builder, got %v", alias)
+	}
+
+	// the AST mode only matches method sets declared on the type itself
+	astParser, err := NewClassDiagram([]string{dir}, nil, false)
+	if err != nil {
+		t.Fatalf("TestTypeCheckedImplementations: expected no error, got %s", err)
+	}
+	if _, ok := astParser.getStruct("example.com/shapes.Wrapped").Extends["io.Reader"]; ok {
+		t.Errorf("TestTypeCheckedImplementations: expected the AST mode to miss promoted methods")
+	}
+	// Wrapped implements io.Reader through the method promoted from Base
+	expected := `@startuml
+left to right direction
+namespace example.com.shapes {
+    class Base << (S,Aquamarine) >> {
+        + Read(p []byte) (int, error)
+
+    }
+    interface Closer  {
+        + Close() error
+
+    }
+    class Pointer << (S,Aquamarine) >> {
+        + Close() error
+
+    }
+    class Value << (S,Aquamarine) >> {
+        + Close() error
+
+    }
+    class Wrapped << (S,Aquamarine) >> {
+    }
+    class example.com.shapes.Builder << (T, #FF7700) >>  {
+    }
+}
+"example.com.shapes.Base" *-- "example.com.shapes.Wrapped"
+
+"io.Reader" <|-- "example.com.shapes.Base"
+"example.com.shapes.Closer" <|-- "example.com.shapes.Pointer"
+"example.com.shapes.Closer" <|-- "example.com.shapes.Value"
+"io.Reader" <|-- "example.com.shapes.Wrapped"
+
+"strings.Builder" #.. "example.com.shapes.Builder"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestTypeCheckedImplementations: expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestTypeCheckedErrors(t *testing.T) {
+	dir := writeTestModule(t, map[string]string{
+		"go.mod":    "module example.com/broken\n\ngo 1.21\n",
+		"broken.go": "package broken\n\nvar x undefined\n",
+	})
+	_, err := NewClassDiagramWithOptions(&ClassDiagramOptions{Directories: []string{dir}, TypeChecked: true})
+	if err == nil || !strings.Contains(err.Error(), "undefined") {
+		t.Errorf("TestTypeCheckedErrors: expected a type error, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package gopuml
+
+import (
+	"bytes"
+	"compress/flate"
+	"fmt"
+	"strings"
+)
+
+//DefaultPlantUMLServer is the public PlantUML server that PlantUMLURL links to by default
+const DefaultPlantUMLServer = "https://www.plantuml.com/plantuml"
+
+//plantUMLAlphabet is the base64 alphabet of the PlantUML text encoding
+const plantUMLAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz-_"
+
+//EncodePlantUML returns the text encoding PlantUML servers read diagrams from in their URLs: the diagram
+//compressed with deflate, then written in the PlantUML base64 alphabet
+func EncodePlantUML(diagram string) (string, error) {
+	var compressed bytes.Buffer
+	writer, err := flate.NewWriter(&compressed, flate.BestCompression)
+	if err != nil {
+		return "", fmt.Errorf("failed to compress the diagram: %v", err)
+	}
+	if _, err := writer.Write([]byte(diagram)); err != nil {
+		return "", fmt.Errorf("failed to compress the diagram: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("failed to compress the diagram: %v", err)
+	}
+	return encode64(compressed.Bytes()), nil
+}
+
+//encode64 writes data in the PlantUML base64 alphabet. Like PlantUML, a last group of one or two bytes is
+//completed with zeros and written as four characters.
+func encode64(data []byte) string {
+	var result strings.Builder
+	for i := 0; i < len(data); i += 3 {
+		var group [3]byte
+		copy(group[:], data[i:])
+		result.WriteByte(plantUMLAlphabet[group[0]>>2])
+		result.WriteByte(plantUMLAlphabet[(group[0]&0x3)<<4|group[1]>>4])
+		result.WriteByte(plantUMLAlphabet[(group[1]&0xF)<<2|group[2]>>6])
+		result.WriteByte(plantUMLAlphabet[group[2]&0x3F])
+	}
+	return result.String()
+}
+
+//PlantUMLURL returns the URL of the image of a diagram on a PlantUML server, server/format/encoded diagram.
+//The format is the kind of image the server renders: svg, png or txt, or uml for its editor.
+func PlantUMLURL(server, format, diagram string) (string, error) {
+	if server == "" {
+		server = DefaultPlantUMLServer
+	}
+	switch format {
+	case "svg", "png", "txt", "uml":
+	default:
+		return "", fmt.Errorf("unknown PlantUML server format %s, expected svg, png, txt or uml", format)
+	}
+	encoded, err := EncodePlantUML(diagram)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(server, "/"), format, encoded), nil
+}
@@ -0,0 +1,89 @@
+package gopuml
+
+import (
+	"compress/flate"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestEncode64(t *testing.T) {
+	tt := []struct {
+		Data     []byte
+		Expected string
+	}{
+		{Data: []byte{}, Expected: ""},
+		{Data: []byte("Man"), Expected: "JM5k"},
+		{Data: []byte("M"), Expected: "JG00"},
+		{Data: []byte("Ma"), Expected: "JM40"},
+		{Data: []byte{0xFF, 0xFF, 0xFF}, Expected: "____"},
+		{Data: []byte{0xFB, 0xEF, 0xBE}, Expected: "----"},
+	}
+	for _, tc := range tt {
+		if result := encode64(tc.Data); result != tc.Expected {
+			t.Errorf("TestEncode64: expected %s for %v, got %s", tc.Expected, tc.Data, result)
+		}
+	}
+}
+
+//decodePlantUML reverses EncodePlantUML
+func decodePlantUML(t *testing.T, encoded string) string {
+	var data []byte
+	for i := 0; i+4 <= len(encoded); i += 4 {
+		var c [4]byte
+		for j := range c {
+			c[j] = byte(strings.IndexByte(plantUMLAlphabet, encoded[i+j]))
+		}
+		data = append(data, c[0]<<2|c[1]>>4, c[1]<<4|c[2]>>2, c[2]<<6|c[3])
+	}
+	// the zeros completing the last group are after the end of the deflate stream
+	result, err := io.ReadAll(flate.NewReader(strings.NewReader(string(data))))
+	if err != nil {
+		t.Fatalf("failed to decode %s: %s", encoded, err)
+	}
+	return string(result)
+}
+
+func TestEncodePlantUML(t *testing.T) {
+	for _, diagram := range []string{
+		"Bob -> Alice : hello",
+		"@startuml\nclass Foo {\n    + Bar() <font color=blue>map</font>[string]int\n}\n@enduml\n",
+		"",
+	} {
+		encoded, err := EncodePlantUML(diagram)
+		if err != nil {
+			t.Fatalf("TestEncodePlantUML: expected no error, got %s", err)
+		}
+		if strings.Trim(encoded, plantUMLAlphabet) != "" || len(encoded)%4 != 0 {
+			t.Errorf("TestEncodePlantUML: unexpected characters in %s", encoded)
+		}
+		if decoded := decodePlantUML(t, encoded); decoded != diagram {
+			t.Errorf("TestEncodePlantUML: expected %q after decoding, got %q", diagram, decoded)
+		}
+	}
+}
+
+func TestPlantUMLURL(t *testing.T) {
+	encoded, _ := EncodePlantUML("Bob -> Alice : hello")
+	tt := []struct {
+		Server   string
+		Format   string
+		Expected string
+	}{
+		{Server: "", Format: "svg", Expected: "https://www.plantuml.com/plantuml/svg/" + encoded},
+		{Server: "http://localhost:8080/", Format: "png", Expected: "http://localhost:8080/png/" + encoded},
+		{Server: "http://localhost:8080", Format: "uml", Expected: "http://localhost:8080/uml/" + encoded},
+	}
+	for _, tc := range tt {
+		result, err := PlantUMLURL(tc.Server, tc.Format, "Bob -> Alice : hello")
+		if err != nil {
+			t.Fatalf("TestPlantUMLURL: expected no error, got %s", err)
+		}
+		if result != tc.Expected {
+			t.Errorf("TestPlantUMLURL: expected %s, got %s", tc.Expected, result)
+		}
+	}
+	if _, err := PlantUMLURL("", "pdf", "Bob -> Alice : hello"); err == nil {
+		t.Errorf("TestPlantUMLURL: expected an error for an unknown format")
+	}
+}
@@ -0,0 +1,371 @@
+package gopuml
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+//Renderer writes the class diagram of a ClassParser in the language of a diagram tool. All renderers honor
+//the rendering options of the parser, except for the style which only PlantUML supports, and the JSON
+//renderer which writes the whole model.
+type Renderer interface {
+	Render(p *ClassParser) string
+}
+
+//FormatPlantUML selects the PlantUML renderer in NewRenderer
+const FormatPlantUML = "plantuml"
+
+//FormatMermaid selects the renderer of Mermaid class diagrams in NewRenderer
+const FormatMermaid = "mermaid"
+
+//FormatDOT selects the Graphviz DOT renderer in NewRenderer
+const FormatDOT = "dot"
+
+//FormatD2 selects the D2 renderer in NewRenderer
+const FormatD2 = "d2"
+
+//FormatJSON selects the JSON renderer of the parsed model in NewRenderer
+const FormatJSON = "json"
+
+//NewRenderer returns the renderer of the given format
+func NewRenderer(format string) (Renderer, error) {
+	switch format {
+	case FormatPlantUML, "":
+		return PlantUMLRenderer{}, nil
+	case FormatMermaid:
+		return MermaidRenderer{}, nil
+	case FormatDOT:
+		return DOTRenderer{}, nil
+	case FormatD2:
+		return D2Renderer{}, nil
+	case FormatJSON:
+		return JSONRenderer{}, nil
+	}
+	return nil, fmt.Errorf("unknown format %s, expected %s, %s, %s, %s or %s", format, FormatPlantUML, FormatMermaid, FormatDOT, FormatD2, FormatJSON)
+}
+
+//PlantUMLRenderer renders PlantUML class diagrams, the output of ClassParser.Render
+type PlantUMLRenderer struct{}
+
+//Render returns the PlantUML class diagram of the parser
+func (PlantUMLRenderer) Render(p *ClassParser) string {
+	return p.Render()
+}
+
+//diagramPackage holds the types of a package as the renderers other than PlantUML draw them
+type diagramPackage struct {
+	Path      string
+	Namespace string
+	Types     []*diagramType
+}
+
+//diagramType is a type with its members filtered by the rendering options
+type diagramType struct {
+	// ID is the package qualified name relations refer to
+	ID string
+	// Name is the name with the type parameters in Go syntax, e.g. Cache[K comparable, V any]
+	Name    string
+	Kind    string
+	TypeSet []string
+	// Constants are the entries of an enum, "Name = Value"
+	Constants []string
+	Fields    []diagramMember
+	Methods   []diagramMember
+	// Doc is the part of the doc comment the RenderDocs option selects
+	Doc string
+}
+
+//diagramMember is a field, "Name Type", or a method, "Name(params) results"
+type diagramMember struct {
+	Public bool
+	Text   string
+}
+
+//diagramRelation is a connection between two types. The decoration is drawn at From, like in the PlantUML
+//diagram: the embedded type of a composition, the interface of an implementation, the type holding an
+//aggregation and the alias.
+type diagramRelation struct {
+	Kind string
+	From string
+	To   string
+}
+
+const relationComposition = "composition"
+const relationExtends = "extends"
+const relationAggregation = "aggregation"
+const relationAlias = "alias"
+const relationCreates = "creates"
+
+//labeled returns true if the relation is drawn with its label. The connections of the utility classes are
+//always labeled, a dependency arrow does not tell what they are otherwise.
+func (r *diagramRelation) labeled(options *RenderingOptions) bool {
+	return options.ConnectionLabels || r.Kind == relationCreates
+}
+
+//label returns the connection label of the relation
+func (r *diagramRelation) label() string {
+	switch r.Kind {
+	case relationComposition:
+		return strings.Trim(extends, `"`)
+	case relationExtends:
+		return strings.Trim(implements, `"`)
+	case relationAggregation:
+		return strings.Trim(aggregates, `"`)
+	case relationCreates:
+		return creates
+	}
+	return strings.Trim(aliasOf, `"`)
+}
+
+//diagram returns the packages and relations of the parser filtered by the rendering options, sorted by name
+func (p *ClassParser) diagram() ([]*diagramPackage, []*diagramRelation) {
+	visible := p.visibleTypes()
+	var packages []*diagramPackage
+	for _, pack := range p.parsedPackages() {
+		structures := p.structure[pack]
+		dp := &diagramPackage{Path: pack, Namespace: p.namespace(pack)}
+		var names []string
+		for name := range structures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		declared := make(map[string]*diagramType)
+		for _, name := range names {
+			if !isVisible(visible, diagramID(pack, name)) {
+				continue
+			}
+			t := p.diagramType(pack, name, structures[name])
+			// the methods of a defined type are kept apart from the type, under its unqualified name
+			if other, ok := declared[t.ID]; ok {
+				other.merge(t)
+				continue
+			}
+			declared[t.ID] = t
+			dp.Types = append(dp.Types, t)
+		}
+		if p.hasUtility(pack, visible) {
+			dp.Types = append(dp.Types, p.diagramType(pack, p.utilityName(pack), p.utilities[pack]))
+		}
+		if len(dp.Types) > 0 {
+			packages = append(packages, dp)
+		}
+	}
+	var relations []*diagramRelation
+	for _, r := range p.relations() {
+		if isVisible(visible, r.From, r.To) {
+			r.From, r.To = relationEnd(r.From), relationEnd(r.To)
+			relations = append(relations, r)
+		}
+	}
+	return packages, relations
+}
+
+//relations returns the relations of all parsed types that the rendering options select
+func (p *ClassParser) relations() []*diagramRelation {
+	var relations []*diagramRelation
+	for _, pack := range p.parsedPackages() {
+		structures := p.structure[pack]
+		var names []string
+		for name := range structures {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			relations = append(relations, p.structureRelations(pack, name, structures[name])...)
+		}
+	}
+	if p.renderingOptions.Aliases {
+		orderedAliases := AliasSlice{}
+		for _, alias := range p.allAliases {
+			orderedAliases = append(orderedAliases, *alias)
+		}
+		sort.Sort(orderedAliases)
+		for _, alias := range orderedAliases {
+			relations = append(relations, &diagramRelation{Kind: relationAlias, From: alias.Name, To: alias.AliasOf})
+		}
+	}
+	return append(relations, p.utilityRelations()...)
+}
+
+var plantUMLMarkup = regexp.MustCompile(`<font color=blue>(\w+)</font>`)
+
+//plainText removes the PlantUML markup of the Go keywords in type names, e.g. <font color=blue>map</font>
+func plainText(text string) string {
+	return strings.TrimSpace(plantUMLMarkup.ReplaceAllString(text, "$1"))
+}
+
+//label returns the plain name of a type that relations refer to
+func (p *ClassParser) label(id string) string {
+	return plainText(p.renderName(id))
+}
+
+//relationEnd returns the plain name of a type a relation refers to. The predeclared types, e.g. []byte,
+//are written without the package the parser keeps them in.
+func relationEnd(name string) string {
+	return strings.TrimPrefix(plainText(name), builtinPackageName+".")
+}
+
+func (p *ClassParser) diagramType(pack, name string, structure *Struct) *diagramType {
+	t := &diagramType{ID: plainText(diagramID(pack, name)), Name: plainText(name), Kind: structure.Type, Doc: p.renderedDoc(structure.Doc)}
+	if strings.Contains(name, ".") {
+		_, t.Name = p.splitName(name)
+		t.Name = plainText(t.Name)
+	}
+	for _, element := range structure.TypeSet {
+		t.TypeSet = append(t.TypeSet, plainText(element))
+	}
+	if t.Kind == "" {
+		t.Kind = "class"
+	}
+	if constants := p.enumConstants(pack, name, structure); len(constants) > 0 {
+		t.Kind = "enum"
+		for _, c := range constants {
+			t.Constants = append(t.Constants, renderConstant(c))
+		}
+	}
+	if len(structure.TypeParams) > 0 {
+		params := make([]string, 0, len(structure.TypeParams))
+		for _, param := range structure.TypeParams {
+			params = append(params, fmt.Sprintf("%s %s", param.Name, plainText(param.Type)))
+		}
+		t.Name = fmt.Sprintf("%s[%s]", t.Name, strings.Join(params, ", "))
+	}
+	if p.renderingOptions.Fields {
+		for _, field := range structure.Fields {
+			text := fmt.Sprintf("%s %s", field.Name, field.Type)
+			if p.renderingOptions.Tags {
+				text += renderTag(field.Tag)
+			}
+			t.Fields = append(t.Fields, diagramMember{Public: !unicode.IsLower(rune(field.Name[0])), Text: plainText(text)})
+		}
+	}
+	if p.renderingOptions.Methods {
+		for _, method := range structure.Functions {
+			parameterList := make([]string, 0, len(method.Parameters))
+			for _, param := range method.Parameters {
+				parameterList = append(parameterList, fmt.Sprintf("%s %s", param.Name, param.Type))
+			}
+			text := fmt.Sprintf("%s(%s)", method.Name, strings.Join(parameterList, ", "))
+			if len(method.ReturnValues) == 1 {
+				text += " " + method.ReturnValues[0]
+			} else if len(method.ReturnValues) > 1 {
+				text += fmt.Sprintf(" (%s)", strings.Join(method.ReturnValues, ", "))
+			}
+			t.Methods = append(t.Methods, diagramMember{Public: !unicode.IsLower(rune(method.Name[0])), Text: plainText(text)})
+		}
+	}
+	return t
+}
+
+//merge adds the members of another part of the same type, e.g. the methods of a defined type. The
+//declaration of the type holds its kind and type parameters.
+func (t *diagramType) merge(other *diagramType) {
+	if t.Kind == "class" {
+		t.Kind, t.Name = other.Kind, other.Name
+	}
+	if t.Doc == "" {
+		t.Doc = other.Doc
+	}
+	t.TypeSet = append(t.TypeSet, other.TypeSet...)
+	t.Constants = append(t.Constants, other.Constants...)
+	t.Fields = append(t.Fields, other.Fields...)
+	t.Methods = append(t.Methods, other.Methods...)
+}
+
+//structureRelations returns the compositions, implementations and aggregations of a structure that the
+//rendering options select, in the order of the PlantUML diagram
+func (p *ClassParser) structureRelations(pack, name string, structure *Struct) []*diagramRelation {
+	var relations []*diagramRelation
+	id := diagramID(pack, name)
+	if p.renderingOptions.Compositions {
+		for _, c := range sortedKeys(structure.Composition) {
+			if !strings.Contains(c, ".") {
+				c = fmt.Sprintf("%s.%s", p.getPackageName(c, structure), c)
+			}
+			relations = append(relations, &diagramRelation{Kind: relationComposition, From: c, To: id})
+		}
+	}
+	if p.renderingOptions.Implementations {
+		for _, c := range sortedKeys(structure.Extends) {
+			if !strings.Contains(c, ".") {
+				c = fmt.Sprintf("%s.%s", structure.PackageName, c)
+			}
+			relations = append(relations, &diagramRelation{Kind: relationExtends, From: c, To: id})
+		}
+	}
+	if p.renderingOptions.Aggregations {
+		aggregations := make(map[string]struct{})
+		for a := range structure.Aggregations {
+			aggregations[a] = struct{}{}
+		}
+		if p.renderingOptions.AggregatePrivateMembers {
+			for a := range structure.PrivateAggregations {
+				aggregations[a] = struct{}{}
+			}
+		}
+		for _, a := range sortedKeys(aggregations) {
+			if !strings.Contains(a, ".") {
+				a = fmt.Sprintf("%s.%s", p.getPackageName(a, structure), a)
+			}
+			if p.getPackageName(a, structure) != builtinPackageName {
+				relations = append(relations, &diagramRelation{Kind: relationAggregation, From: id, To: a})
+			}
+		}
+	}
+	return relations
+}
+
+//diagramID returns the package qualified name of a type of a package. Defined types that are not structs
+//or interfaces are kept under their qualified name already.
+func diagramID(pack, name string) string {
+	if strings.Contains(name, ".") {
+		return name
+	}
+	return pack + "." + name
+}
+
+//privateFirst returns the private members before the public ones, like in the PlantUML diagram
+func privateFirst(members []diagramMember) []diagramMember {
+	var private, public []diagramMember
+	for _, m := range members {
+		if m.Public {
+			public = append(public, m)
+		} else {
+			private = append(private, m)
+		}
+	}
+	return append(private, public...)
+}
+
+func sortedKeys(set map[string]struct{}) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+//undeclared returns the types relations refer to that are not part of the packages, e.g. io.Reader,
+//in the order they are first referred to
+func undeclared(packages []*diagramPackage, relations []*diagramRelation) []string {
+	declared := make(map[string]bool)
+	for _, pack := range packages {
+		for _, t := range pack.Types {
+			declared[t.ID] = true
+		}
+	}
+	var result []string
+	for _, r := range relations {
+		for _, id := range []string{r.From, r.To} {
+			if !declared[id] {
+				declared[id] = true
+				result = append(result, id)
+			}
+		}
+	}
+	return result
+}
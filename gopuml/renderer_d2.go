@@ -0,0 +1,115 @@
+package gopuml
+
+import (
+	"fmt"
+	"strings"
+)
+
+//D2Renderer renders D2 diagrams with a class shape per type and a container per package
+type D2Renderer struct{}
+
+//d2String quotes a D2 key or value
+func d2String(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
+
+//Render returns the D2 diagram of the parser
+func (D2Renderer) Render(p *ClassParser) string {
+	packages, relations := p.diagram()
+	str := &LineStringBuilder{}
+	if p.renderingOptions.Direction == DirectionTopToBottom {
+		str.WriteLineWithDepth(0, "direction: down")
+	} else {
+		str.WriteLineWithDepth(0, "direction: right")
+	}
+	if p.renderingOptions.Title != "" {
+		str.WriteLineWithDepth(0, fmt.Sprintf("title: %s {", d2String(p.renderingOptions.Title)))
+		str.WriteLineWithDepth(1, "near: top-center")
+		str.WriteLineWithDepth(1, "shape: text")
+		str.WriteLineWithDepth(0, "}")
+	}
+	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
+		str.WriteLineWithDepth(0, fmt.Sprintf("legend: %s {", d2String(note)))
+		str.WriteLineWithDepth(1, "near: bottom-right")
+		str.WriteLineWithDepth(1, "shape: page")
+		str.WriteLineWithDepth(0, "}")
+	}
+	// relations refer to the declared types through their package container
+	refs := make(map[string]string)
+	for _, pack := range packages {
+		str.WriteLineWithDepth(0, fmt.Sprintf("%s: {", d2String(pack.Namespace)))
+		for _, t := range pack.Types {
+			key := d2String(strings.TrimPrefix(t.ID, pack.Path+"."))
+			refs[t.ID] = d2String(pack.Namespace) + "." + key
+			str.WriteLineWithDepth(1, fmt.Sprintf("%s: {", key))
+			str.WriteLineWithDepth(2, "shape: class")
+			label := t.Name
+			if t.Kind != "class" {
+				label = fmt.Sprintf("«%s» %s", t.Kind, t.Name)
+			}
+			str.WriteLineWithDepth(2, fmt.Sprintf("label: %s", d2String(label)))
+			if t.Doc != "" {
+				str.WriteLineWithDepth(2, fmt.Sprintf("tooltip: %s", d2String(t.Doc)))
+			}
+			for _, element := range append(t.TypeSet, t.Constants...) {
+				str.WriteLineWithDepth(2, fmt.Sprintf(`%s: ""`, d2String(element)))
+			}
+			for _, m := range privateFirst(t.Fields) {
+				name, fieldType, _ := strings.Cut(m.Text, " ")
+				str.WriteLineWithDepth(2, fmt.Sprintf("%s: %s", d2String(d2Visibility(m)+name), d2String(fieldType)))
+			}
+			for _, m := range privateFirst(t.Methods) {
+				// the results follow the closing parenthesis of the parameters
+				signature, results := m.Text, ""
+				if i := strings.LastIndex(m.Text, ") "); i >= 0 && strings.Count(m.Text[:i+1], "(") == strings.Count(m.Text[:i+1], ")") {
+					signature, results = m.Text[:i+1], m.Text[i+2:]
+				}
+				str.WriteLineWithDepth(2, fmt.Sprintf("%s: %s", d2String(d2Visibility(m)+signature), d2String(results)))
+			}
+			str.WriteLineWithDepth(1, "}")
+		}
+		str.WriteLineWithDepth(0, "}")
+	}
+	for _, id := range undeclared(packages, relations) {
+		refs[id] = d2String(p.label(id))
+	}
+
+	// the decoration of a relation is drawn at From, so the connection goes from To to From
+	arrowheads := map[string][]string{
+		relationComposition: {"shape: diamond", "style.filled: true"},
+		relationExtends:     {"shape: triangle", "style.filled: false"},
+		relationAggregation: {"shape: diamond", "style.filled: false"},
+	}
+	for _, r := range relations {
+		connection := fmt.Sprintf("%s -> %s", refs[r.To], refs[r.From])
+		switch r.Kind {
+		case relationAlias:
+			connection = fmt.Sprintf("%s -- %s", refs[r.To], refs[r.From])
+		case relationCreates:
+			// a dependency points to the created type
+			connection = fmt.Sprintf("%s -> %s", refs[r.From], refs[r.To])
+		}
+		if r.labeled(p.renderingOptions) {
+			connection += ": " + d2String(r.label())
+		} else {
+			connection += ":"
+		}
+		str.WriteLineWithDepth(0, connection+" {")
+		if r.Kind == relationAlias || r.Kind == relationCreates {
+			str.WriteLineWithDepth(1, "style.stroke-dash: 3")
+		}
+		for _, attribute := range arrowheads[r.Kind] {
+			str.WriteLineWithDepth(1, "target-arrowhead."+attribute)
+		}
+		str.WriteLineWithDepth(0, "}")
+	}
+	return str.String()
+}
+
+//d2Visibility returns the UML visibility sign D2 reads from the start of class members
+func d2Visibility(m diagramMember) string {
+	if m.Public {
+		return "+"
+	}
+	return "-"
+}
@@ -0,0 +1,121 @@
+package gopuml
+
+import (
+	"fmt"
+	"strings"
+)
+
+//DOTRenderer renders Graphviz DOT graphs with a record node per type and a cluster per package
+type DOTRenderer struct{}
+
+//dotString quotes a DOT identifier or label
+func dotString(text string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(text) + `"`
+}
+
+//dotRecord escapes the characters of a record label that delimit fields and ports
+func dotRecord(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "{", `\{`, "}", `\}`, "|", `\|`, "<", `\<`, ">", `\>`, `"`, `\"`).Replace(text)
+}
+
+//Render returns the DOT graph of the parser
+func (DOTRenderer) Render(p *ClassParser) string {
+	packages, relations := p.diagram()
+	str := &LineStringBuilder{}
+	str.WriteLineWithDepth(0, "digraph gopuml {")
+	if p.renderingOptions.Direction == DirectionTopToBottom {
+		str.WriteLineWithDepth(1, "rankdir=TB")
+	} else {
+		str.WriteLineWithDepth(1, "rankdir=LR")
+	}
+	str.WriteLineWithDepth(1, `node [shape=record, fontname="Helvetica"]`)
+	if p.renderingOptions.Title != "" {
+		str.WriteLineWithDepth(1, fmt.Sprintf("label=%s", dotString(p.renderingOptions.Title)))
+		str.WriteLineWithDepth(1, "labelloc=t")
+	}
+	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
+		str.WriteLineWithDepth(1, fmt.Sprintf("legend [shape=note, label=%s]", dotString(note)))
+	}
+	for i, pack := range packages {
+		str.WriteLineWithDepth(1, fmt.Sprintf("subgraph cluster_%d {", i))
+		str.WriteLineWithDepth(2, fmt.Sprintf("label=%s", dotString(pack.Namespace)))
+		for _, t := range pack.Types {
+			str.WriteLineWithDepth(2, fmt.Sprintf(`%s [label="%s"]`, dotString(t.ID), dotTypeLabel(t, p.renderingOptions)))
+			if t.Doc != "" {
+				note := dotString(t.ID + " doc")
+				str.WriteLineWithDepth(2, fmt.Sprintf("%s [shape=note, label=%s]", note, dotString(t.Doc)))
+				str.WriteLineWithDepth(2, fmt.Sprintf("%s -> %s [style=dashed, arrowhead=none]", note, dotString(t.ID)))
+			}
+		}
+		str.WriteLineWithDepth(1, "}")
+	}
+	for _, id := range undeclared(packages, relations) {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`%s [label="%s"]`, dotString(id), dotRecord(p.label(id))))
+	}
+	for _, r := range relations {
+		var attributes []string
+		switch r.Kind {
+		case relationComposition:
+			attributes = append(attributes, "dir=back", "arrowtail=diamond")
+		case relationExtends:
+			attributes = append(attributes, "dir=back", "arrowtail=empty")
+		case relationAggregation:
+			attributes = append(attributes, "dir=back", "arrowtail=odiamond")
+		case relationAlias:
+			attributes = append(attributes, "dir=none", "style=dashed")
+		case relationCreates:
+			attributes = append(attributes, "style=dashed", "arrowhead=vee")
+		}
+		if r.labeled(p.renderingOptions) {
+			attributes = append(attributes, fmt.Sprintf("label=%s", dotString(r.label())))
+		}
+		str.WriteLineWithDepth(1, fmt.Sprintf("%s -> %s [%s]", dotString(r.From), dotString(r.To), strings.Join(attributes, ", ")))
+	}
+	str.WriteLineWithDepth(0, "}")
+	return str.String()
+}
+
+//dotTypeLabel returns the record label of a type: its name, then its fields and its methods in
+//compartments, left justified
+func dotTypeLabel(t *diagramType, options *RenderingOptions) string {
+	title := dotRecord(t.Name)
+	if t.Kind != "class" {
+		title = fmt.Sprintf(`«%s»\n%s`, t.Kind, title)
+	}
+	compartments := []string{title}
+	if len(t.TypeSet) > 0 {
+		compartments = append(compartments, dotMembers(t.TypeSet))
+	}
+	if len(t.Constants) > 0 {
+		compartments = append(compartments, dotMembers(t.Constants))
+	}
+	if options.Fields {
+		compartments = append(compartments, dotMembers(memberLines(t.Fields)))
+	}
+	if options.Methods {
+		compartments = append(compartments, dotMembers(memberLines(t.Methods)))
+	}
+	return strings.Join(compartments, "|")
+}
+
+func dotMembers(lines []string) string {
+	var result strings.Builder
+	for _, line := range lines {
+		result.WriteString(dotRecord(line))
+		result.WriteString(`\l`)
+	}
+	return result.String()
+}
+
+//memberLines returns the members with the UML visibility sign, e.g. "+ Name string"
+func memberLines(members []diagramMember) []string {
+	var lines []string
+	for _, m := range privateFirst(members) {
+		if m.Public {
+			lines = append(lines, "+ "+m.Text)
+		} else {
+			lines = append(lines, "- "+m.Text)
+		}
+	}
+	return lines
+}
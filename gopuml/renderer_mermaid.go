@@ -0,0 +1,89 @@
+package gopuml
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+//MermaidRenderer renders Mermaid classDiagram diagrams. Mermaid identifiers can only hold letters, digits
+//and underscores, so the types are labeled with their names.
+type MermaidRenderer struct{}
+
+var mermaidInvalid = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+//mermaidID returns the identifier of a package qualified name
+func mermaidID(name string) string {
+	return mermaidInvalid.ReplaceAllString(name, "_")
+}
+
+//mermaidText escapes the characters Mermaid reads as syntax in labels and members: braces end class
+//bodies and tildes enclose generics
+func mermaidText(text string) string {
+	return strings.NewReplacer("{", "#123;", "}", "#125;", "~", "#126;", `"`, "#quot;").Replace(text)
+}
+
+//Render returns the Mermaid class diagram of the parser
+func (MermaidRenderer) Render(p *ClassParser) string {
+	packages, relations := p.diagram()
+	str := &LineStringBuilder{}
+	if p.renderingOptions.Title != "" {
+		str.WriteLineWithDepth(0, "---")
+		str.WriteLineWithDepth(0, fmt.Sprintf("title: %s", p.renderingOptions.Title))
+		str.WriteLineWithDepth(0, "---")
+	}
+	str.WriteLineWithDepth(0, "classDiagram")
+	if p.renderingOptions.Direction == DirectionTopToBottom {
+		str.WriteLineWithDepth(1, "direction TB")
+	} else {
+		str.WriteLineWithDepth(1, "direction LR")
+	}
+	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`note "%s"`, mermaidText(strings.Replace(note, "\n", "<br>", -1))))
+	}
+	members := &LineStringBuilder{}
+	for _, pack := range packages {
+		str.WriteLineWithDepth(1, fmt.Sprintf("namespace %s {", mermaidID(pack.Namespace)))
+		for _, t := range pack.Types {
+			id := mermaidID(t.ID)
+			str.WriteLineWithDepth(2, fmt.Sprintf(`class %s["%s"]`, id, mermaidText(t.Name)))
+			if t.Kind != "class" {
+				members.WriteLineWithDepth(1, fmt.Sprintf("<<%s>> %s", t.Kind, id))
+			}
+			if t.Doc != "" {
+				members.WriteLineWithDepth(1, fmt.Sprintf(`note for %s "%s"`, id, mermaidText(strings.Replace(t.Doc, "\n", "<br>", -1))))
+			}
+			for _, element := range append(t.TypeSet, t.Constants...) {
+				members.WriteLineWithDepth(1, fmt.Sprintf("%s : %s", id, mermaidText(element)))
+			}
+			for _, m := range append(privateFirst(t.Fields), privateFirst(t.Methods)...) {
+				visibility := "-"
+				if m.Public {
+					visibility = "+"
+				}
+				members.WriteLineWithDepth(1, fmt.Sprintf("%s : %s%s", id, visibility, mermaidText(m.Text)))
+			}
+		}
+		str.WriteLineWithDepth(1, "}")
+	}
+	for _, id := range undeclared(packages, relations) {
+		str.WriteLineWithDepth(1, fmt.Sprintf(`class %s["%s"]`, mermaidID(id), mermaidText(p.label(id))))
+	}
+	str.WriteString(members.String())
+
+	arrows := map[string]string{
+		relationComposition: "*--",
+		relationExtends:     "<|--",
+		relationAggregation: "o--",
+		relationAlias:       "..",
+		relationCreates:     "..>",
+	}
+	for _, r := range relations {
+		label := ""
+		if r.labeled(p.renderingOptions) {
+			label = " : " + r.label()
+		}
+		str.WriteLineWithDepth(1, fmt.Sprintf("%s %s %s%s", mermaidID(r.From), arrows[r.Kind], mermaidID(r.To), label))
+	}
+	return str.String()
+}
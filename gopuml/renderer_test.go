@@ -0,0 +1,342 @@
+package gopuml
+
+import "testing"
+
+var rendererModule = map[string]string{
+	"go.mod": "module example.com/geo\n",
+	"geo.go": `package geo
+
+import "io"
+
+type Shape interface {
+	Area() float64
+}
+
+type Base struct {
+	name string
+}
+
+type Square struct {
+	Base
+	Side  float64
+	Attrs map[string]string
+	Out   io.Writer
+}
+
+func (s *Square) Area() float64 { return s.Side * s.Side }
+
+type Box[T any] struct {
+	Items []T
+}
+`,
+}
+
+var aliasMethodsModule = map[string]string{
+	"go.mod": "module example.com/pkt\n",
+	"pkt.go": `package pkt
+
+type Header []byte
+
+func (h Header) Len() int { return len(h) }
+
+type Filter func(Header) bool
+
+type Set[T comparable] map[T]struct{}
+
+func (s Set[T]) Has(v T) bool { return false }
+`,
+}
+
+func newRendererTestParser(t *testing.T, files map[string]string, options map[RenderingOption]interface{}) *ClassParser {
+	t.Helper()
+	dir := writeTestModule(t, files)
+	parser, err := NewClassDiagram([]string{dir}, nil, false)
+	if err != nil {
+		t.Fatalf("expected no error, got %s", err)
+	}
+	parser.SetRenderingOptions(options)
+	return parser
+}
+
+func TestNewRenderer(t *testing.T) {
+	for _, format := range []string{"", FormatPlantUML, FormatMermaid, FormatDOT, FormatD2, FormatJSON} {
+		if _, err := NewRenderer(format); err != nil {
+			t.Errorf("TestNewRenderer: expected no error for %q, got %s", format, err)
+		}
+	}
+	if _, err := NewRenderer("svg"); err == nil {
+		t.Errorf("TestNewRenderer: expected an error for an unknown format")
+	}
+	parser := newRendererTestParser(t, rendererModule, nil)
+	if (PlantUMLRenderer{}).Render(parser) != parser.Render() {
+		t.Errorf("TestNewRenderer: expected the PlantUML renderer to render like ClassParser.Render")
+	}
+}
+
+func TestRenderers(t *testing.T) {
+	parser := newRendererTestParser(t, rendererModule, map[RenderingOption]interface{}{
+		RenderAggregations:     true,
+		RenderConnectionLabels: true,
+		RenderTitle:            "Shapes",
+	})
+	tt := []struct {
+		Renderer Renderer
+		Expected string
+	}{
+		{
+			Renderer: MermaidRenderer{},
+			Expected: `---
+title: Shapes
+---
+classDiagram
+    direction LR
+    namespace example_com_geo {
+        class example_com_geo_Base["Base"]
+        class example_com_geo_Box["Box[T any]"]
+        class example_com_geo_Shape["Shape"]
+        class example_com_geo_Square["Square"]
+    }
+    class io_Writer["io.Writer"]
+    example_com_geo_Base : -name string
+    example_com_geo_Box : +Items []T
+    <<interface>> example_com_geo_Shape
+    example_com_geo_Shape : +Area() float64
+    example_com_geo_Square : +Side float64
+    example_com_geo_Square : +Attrs map[string]string
+    example_com_geo_Square : +Out io.Writer
+    example_com_geo_Square : +Area() float64
+    example_com_geo_Base *-- example_com_geo_Square : extends
+    example_com_geo_Shape <|-- example_com_geo_Square : implements
+    example_com_geo_Square o-- io_Writer : uses
+`,
+		},
+		{
+			Renderer: DOTRenderer{},
+			Expected: `digraph gopuml {
+    rankdir=LR
+    node [shape=record, fontname="Helvetica"]
+    label="Shapes"
+    labelloc=t
+    subgraph cluster_0 {
+        label="example.com.geo"
+        "example.com/geo.Base" [label="Base|- name string\l|"]
+        "example.com/geo.Box" [label="Box[T any]|+ Items []T\l|"]
+        "example.com/geo.Shape" [label="«interface»\nShape||+ Area() float64\l"]
+        "example.com/geo.Square" [label="Square|+ Side float64\l+ Attrs map[string]string\l+ Out io.Writer\l|+ Area() float64\l"]
+    }
+    "io.Writer" [label="io.Writer"]
+    "example.com/geo.Base" -> "example.com/geo.Square" [dir=back, arrowtail=diamond, label="extends"]
+    "example.com/geo.Shape" -> "example.com/geo.Square" [dir=back, arrowtail=empty, label="implements"]
+    "example.com/geo.Square" -> "io.Writer" [dir=back, arrowtail=odiamond, label="uses"]
+}
+`,
+		},
+		{
+			Renderer: D2Renderer{},
+			Expected: `direction: right
+title: "Shapes" {
+    near: top-center
+    shape: text
+}
+"example.com.geo": {
+    "Base": {
+        shape: class
+        label: "Base"
+        "-name": "string"
+    }
+    "Box": {
+        shape: class
+        label: "Box[T any]"
+        "+Items": "[]T"
+    }
+    "Shape": {
+        shape: class
+        label: "«interface» Shape"
+        "+Area()": "float64"
+    }
+    "Square": {
+        shape: class
+        label: "Square"
+        "+Side": "float64"
+        "+Attrs": "map[string]string"
+        "+Out": "io.Writer"
+        "+Area()": "float64"
+    }
+}
+"example.com.geo"."Square" -> "example.com.geo"."Base": "extends" {
+    target-arrowhead.shape: diamond
+    target-arrowhead.style.filled: true
+}
+"example.com.geo"."Square" -> "example.com.geo"."Shape": "implements" {
+    target-arrowhead.shape: triangle
+    target-arrowhead.style.filled: false
+}
+"io.Writer" -> "example.com.geo"."Square": "uses" {
+    target-arrowhead.shape: diamond
+    target-arrowhead.style.filled: false
+}
+`,
+		},
+	}
+	for _, tc := range tt {
+		if result := tc.Renderer.Render(parser); result != tc.Expected {
+			t.Errorf("TestRenderers: expected the %T output\n%s\ngot\n%s", tc.Renderer, tc.Expected, result)
+		}
+	}
+}
+
+func TestRenderersOptions(t *testing.T) {
+	parser := newRendererTestParser(t, rendererModule, map[RenderingOption]interface{}{
+		RenderFields:          false,
+		RenderMethods:         false,
+		RenderCompositions:    false,
+		RenderImplementations: false,
+	})
+	tt := []struct {
+		Renderer Renderer
+		Expected string
+	}{
+		{
+			Renderer: MermaidRenderer{},
+			Expected: `classDiagram
+    direction LR
+    namespace example_com_geo {
+        class example_com_geo_Base["Base"]
+        class example_com_geo_Box["Box[T any]"]
+        class example_com_geo_Shape["Shape"]
+        class example_com_geo_Square["Square"]
+    }
+    <<interface>> example_com_geo_Shape
+`,
+		},
+		{
+			Renderer: DOTRenderer{},
+			Expected: `digraph gopuml {
+    rankdir=LR
+    node [shape=record, fontname="Helvetica"]
+    subgraph cluster_0 {
+        label="example.com.geo"
+        "example.com/geo.Base" [label="Base"]
+        "example.com/geo.Box" [label="Box[T any]"]
+        "example.com/geo.Shape" [label="«interface»\nShape"]
+        "example.com/geo.Square" [label="Square"]
+    }
+}
+`,
+		},
+		{
+			Renderer: D2Renderer{},
+			Expected: `direction: right
+"example.com.geo": {
+    "Base": {
+        shape: class
+        label: "Base"
+    }
+    "Box": {
+        shape: class
+        label: "Box[T any]"
+    }
+    "Shape": {
+        shape: class
+        label: "«interface» Shape"
+    }
+    "Square": {
+        shape: class
+        label: "Square"
+    }
+}
+`,
+		},
+	}
+	for _, tc := range tt {
+		if result := tc.Renderer.Render(parser); result != tc.Expected {
+			t.Errorf("TestRenderersOptions: expected the %T output\n%s\ngot\n%s", tc.Renderer, tc.Expected, result)
+		}
+	}
+}
+
+func TestRenderersAliasMethods(t *testing.T) {
+	parser := newRendererTestParser(t, aliasMethodsModule, map[RenderingOption]interface{}{RenderAliases: true})
+	tt := []struct {
+		Renderer Renderer
+		Expected string
+	}{
+		{
+			Renderer: MermaidRenderer{},
+			Expected: `classDiagram
+    direction LR
+    namespace example_com_pkt {
+        class example_com_pkt_Header["Header"]
+        class example_com_pkt_Set["Set[T comparable]"]
+        class example_com_pkt_Filter["Filter"]
+    }
+    class map_T_struct__["map[T]struct#123;#125;"]
+    class __byte["[]byte"]
+    class example_com_pkt_func_Header__bool["example.com.pkt.func(Header) bool"]
+    <<alias>> example_com_pkt_Header
+    example_com_pkt_Header : +Len() int
+    <<alias>> example_com_pkt_Set
+    example_com_pkt_Set : +Has(v T) bool
+    <<alias>> example_com_pkt_Filter
+    map_T_struct__ .. example_com_pkt_Set
+    __byte .. example_com_pkt_Header
+    example_com_pkt_func_Header__bool .. example_com_pkt_Filter
+`,
+		},
+		{
+			Renderer: DOTRenderer{},
+			Expected: `digraph gopuml {
+    rankdir=LR
+    node [shape=record, fontname="Helvetica"]
+    subgraph cluster_0 {
+        label="example.com.pkt"
+        "example.com/pkt.Header" [label="«alias»\nHeader||+ Len() int\l"]
+        "example.com/pkt.Set" [label="«alias»\nSet[T comparable]||+ Has(v T) bool\l"]
+        "example.com/pkt.Filter" [label="«alias»\nFilter||"]
+    }
+    "map[T]struct{}" [label="map[T]struct\{\}"]
+    "[]byte" [label="[]byte"]
+    "example.com/pkt.func(Header) bool" [label="example.com.pkt.func(Header) bool"]
+    "map[T]struct{}" -> "example.com/pkt.Set" [dir=none, style=dashed]
+    "[]byte" -> "example.com/pkt.Header" [dir=none, style=dashed]
+    "example.com/pkt.func(Header) bool" -> "example.com/pkt.Filter" [dir=none, style=dashed]
+}
+`,
+		},
+		{
+			Renderer: D2Renderer{},
+			Expected: `direction: right
+"example.com.pkt": {
+    "Header": {
+        shape: class
+        label: "«alias» Header"
+        "+Len()": "int"
+    }
+    "Set": {
+        shape: class
+        label: "«alias» Set[T comparable]"
+        "+Has(v T)": "bool"
+    }
+    "Filter": {
+        shape: class
+        label: "«alias» Filter"
+    }
+}
+"example.com.pkt"."Set" -- "map[T]struct{}": {
+    style.stroke-dash: 3
+}
+"example.com.pkt"."Header" -- "[]byte": {
+    style.stroke-dash: 3
+}
+"example.com.pkt"."Filter" -- "example.com.pkt.func(Header) bool": {
+    style.stroke-dash: 3
+}
+`,
+		},
+	}
+	for _, tc := range tt {
+		if result := tc.Renderer.Render(parser); result != tc.Expected {
+			t.Errorf("TestRenderersAliasMethods: expected the %T output\n%s\ngot\n%s", tc.Renderer, tc.Expected, result)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package gopuml
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+)
+
+//ReportText selects the text table of RenderImplementations
+const ReportText = "text"
+
+//ReportJSON selects the JSON array of RenderImplementations
+const ReportJSON = "json"
+
+//InterfaceImplementations is an interface and the parsed types that implement it, by their package qualified
+//names, e.g. github.com/larryr/tools/gopuml.Renderer
+type InterfaceImplementations struct {
+	Interface       string   `json:"interface"`
+	Implementations []string `json:"implementations"`
+}
+
+//Implementations returns the interfaces of the parsed packages, and the imported ones parsed types implement,
+//with their implementations sorted by name. The types are filtered like in the diagrams.
+func (p *ClassParser) Implementations() []*InterfaceImplementations {
+	return p.implementations(p.visibleTypes())
+}
+
+//implementations returns the interfaces and their implementations among the visible types, or among all
+//types when visible is nil
+func (p *ClassParser) implementations(visible map[string]bool) []*InterfaceImplementations {
+	implementations := make(map[string][]string)
+	for _, pack := range p.parsedPackages() {
+		for name, structure := range p.structure[pack] {
+			id := diagramID(pack, name)
+			if structure.Type == "interface" && isVisible(visible, id) {
+				if _, ok := implementations[id]; !ok {
+					implementations[id] = []string{}
+				}
+			}
+			if !isVisible(visible, id) {
+				continue
+			}
+			for iface := range structure.Extends {
+				if !strings.Contains(iface, ".") {
+					iface = fmt.Sprintf("%s.%s", structure.PackageName, iface)
+				}
+				if isVisible(visible, iface) {
+					implementations[iface] = append(implementations[iface], id)
+				}
+			}
+		}
+	}
+	var interfaces []string
+	for iface := range implementations {
+		interfaces = append(interfaces, iface)
+	}
+	sort.Strings(interfaces)
+	var result []*InterfaceImplementations
+	for _, iface := range interfaces {
+		sort.Strings(implementations[iface])
+		result = append(result, &InterfaceImplementations{Interface: iface, Implementations: implementations[iface]})
+	}
+	return result
+}
+
+//RenderImplementations returns the report of Implementations as a text table, one interface per line, or as
+//JSON
+func (p *ClassParser) RenderImplementations(format string) (string, error) {
+	report := p.Implementations()
+	switch format {
+	case ReportText, "":
+		var str strings.Builder
+		writer := tabwriter.NewWriter(&str, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(writer, "INTERFACE\tIMPLEMENTATIONS")
+		for _, i := range report {
+			implementations := strings.Join(i.Implementations, ", ")
+			if implementations == "" {
+				implementations = "-"
+			}
+			fmt.Fprintf(writer, "%s\t%s\n", i.Interface, implementations)
+		}
+		writer.Flush()
+		return str.String(), nil
+	case ReportJSON:
+		if report == nil {
+			report = []*InterfaceImplementations{}
+		}
+		result, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return "", fmt.Errorf("failed to write the implementations report: %v", err)
+		}
+		return string(result) + "\n", nil
+	}
+	return "", fmt.Errorf("unknown report format %s, expected %s or %s", format, ReportText, ReportJSON)
+}
+
+//ReadRequiredInterfaces reads a list of interfaces, one per line as importpath.Interface or package.Interface.
+//Empty lines and lines starting with # are skipped.
+func ReadRequiredInterfaces(reader io.Reader) ([]string, error) {
+	var result []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		result = append(result, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read the required interfaces: %v", err)
+	}
+	return result, nil
+}
+
+//CheckImplementations returns an error listing the required interfaces that no parsed type implements
+//anymore, or that do not exist. Imported interfaces are given with their import path, e.g. io.Reader.
+//All parsed types count, the focus, include and exclude options only filter what is rendered.
+func (p *ClassParser) CheckImplementations(required []string) error {
+	implemented := make(map[string]bool)
+	for _, i := range p.implementations(nil) {
+		implemented[i.Interface] = len(i.Implementations) > 0
+	}
+	var failures []string
+	for _, name := range required {
+		id, err := p.resolveType(name)
+		if _, ok := implemented[name]; ok {
+			// an imported interface, e.g. io.Reader
+			id, err = name, nil
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+			continue
+		}
+		if !implemented[id] {
+			failures = append(failures, fmt.Sprintf("%s has no implementations", name))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("failed to find the required implementations:\n%s", strings.Join(failures, "\n"))
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package gopuml
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+var reportModule = map[string]string{
+	"go.mod": "module example.com/store\n",
+	"store.go": `package store
+
+type Store interface {
+	Get(key string) string
+}
+
+type Closer interface {
+	Close() error
+}
+
+type Memory struct{}
+
+func (m *Memory) Get(key string) string { return "" }
+
+type Disk struct{}
+
+func (d *Disk) Get(key string) string { return "" }
+`,
+}
+
+func TestImplementations(t *testing.T) {
+	dir := writeTestModule(t, reportModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestImplementations: expected no error, got %s", err)
+	}
+	text, err := parser.RenderImplementations(ReportText)
+	if err != nil {
+		t.Fatalf("TestImplementations: expected no error, got %s", err)
+	}
+	expected := "INTERFACE                 IMPLEMENTATIONS\n" +
+		"example.com/store.Closer  -\n" +
+		"example.com/store.Store   example.com/store.Disk, example.com/store.Memory\n"
+	if text != expected {
+		t.Errorf("TestImplementations: expected\n%s\ngot\n%s", expected, text)
+	}
+
+	json, err := parser.RenderImplementations(ReportJSON)
+	if err != nil {
+		t.Fatalf("TestImplementations: expected no error, got %s", err)
+	}
+	expected = `[
+  {
+    "interface": "example.com/store.Closer",
+    "implementations": []
+  },
+  {
+    "interface": "example.com/store.Store",
+    "implementations": [
+      "example.com/store.Disk",
+      "example.com/store.Memory"
+    ]
+  }
+]
+`
+	if json != expected {
+		t.Errorf("TestImplementations: expected\n%s\ngot\n%s", expected, json)
+	}
+	if _, err := parser.RenderImplementations("yaml"); err == nil {
+		t.Errorf("TestImplementations: expected an error for an unknown format")
+	}
+
+	if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderExclude: []string{`\.Disk$`}}); err != nil {
+		t.Fatalf("TestImplementations: expected no error, got %s", err)
+	}
+	var report []InterfaceImplementations
+	for _, i := range parser.Implementations() {
+		report = append(report, *i)
+	}
+	expectedReport := []InterfaceImplementations{
+		{Interface: "example.com/store.Closer", Implementations: []string{}},
+		{Interface: "example.com/store.Store", Implementations: []string{"example.com/store.Memory"}},
+	}
+	if !reflect.DeepEqual(report, expectedReport) {
+		t.Errorf("TestImplementations: expected %v without the excluded type, got %v", expectedReport, report)
+	}
+}
+
+func TestCheckImplementations(t *testing.T) {
+	dir := writeTestModule(t, reportModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestCheckImplementations: expected no error, got %s", err)
+	}
+	required, err := ReadRequiredInterfaces(strings.NewReader("# storage\nstore.Store\n\n  example.com/store.Store  \n"))
+	if err != nil {
+		t.Fatalf("TestCheckImplementations: expected no error, got %s", err)
+	}
+	if err := parser.CheckImplementations(required); err != nil {
+		t.Errorf("TestCheckImplementations: expected no error, got %s", err)
+	}
+	err = parser.CheckImplementations([]string{"store.Store", "store.Closer", "store.Missing"})
+	if err == nil {
+		t.Fatalf("TestCheckImplementations: expected an error")
+	}
+	expectedError := `failed to find the required implementations:
+store.Closer has no implementations
+store.Missing: failed to find the type store.Missing`
+	if err.Error() != expectedError {
+		t.Errorf("TestCheckImplementations: expected\n%s\ngot\n%s", expectedError, err)
+	}
+
+	// types excluded from the diagram still implement the interface
+	if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderExclude: []string{`\.(Disk|Memory)$`}}); err != nil {
+		t.Fatalf("TestCheckImplementations: expected no error, got %s", err)
+	}
+	if err := parser.CheckImplementations([]string{"store.Store"}); err != nil {
+		t.Errorf("TestCheckImplementations: expected the excluded implementations to count, got %s", err)
+	}
+}
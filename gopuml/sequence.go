@@ -0,0 +1,336 @@
+package gopuml
+
+import (
+	"fmt"
+	"go/types"
+	"regexp"
+	"strings"
+
+	"golang.org/x/tools/go/callgraph"
+	"golang.org/x/tools/go/callgraph/cha"
+	"golang.org/x/tools/go/packages"
+	"golang.org/x/tools/go/ssa"
+	"golang.org/x/tools/go/ssa/ssautil"
+)
+
+//SequenceOptions selects the entry point and the calls of a sequence diagram
+type SequenceOptions struct {
+	// EntryPoint is the function the sequence starts with, e.g. nclient4.(*Client).Request,
+	// nclient4.Client.Request or nclient4.New. The package is given by its import path or name.
+	EntryPoint string
+	// MaxDepth limits how deep calls are followed. Zero follows all of them.
+	MaxDepth int
+	// Packages are regular expressions of import paths whose functions are shown besides the
+	// functions of the loaded packages, e.g. ^github.com/insomniacslk/dhcp/
+	Packages []string
+	// ExcludedPackages are regular expressions of import paths whose functions are hidden
+	ExcludedPackages []string
+}
+
+//sequenceBuilder walks the call graph from the entry point and writes the messages of the diagram
+type sequenceBuilder struct {
+	options      *SequenceOptions
+	graph        *callgraph.Graph
+	loaded       map[string]bool
+	included     []*regexp.Regexp
+	excluded     []*regexp.Regexp
+	participants *LineStringBuilder
+	aliases      map[string]string
+	messages     *LineStringBuilder
+	active       map[*ssa.Function]bool
+}
+
+//RenderSequence returns a string of the sequence diagram of the calls made by the entry point of the
+//options. The calls are taken from the class hierarchy analysis of the SSA form of the loaded packages,
+//so the parser must be in the type checked mode. Calls through interfaces with several implementations
+//are sent to the interface and not followed, and calls of function values are left out.
+func (p *ClassParser) RenderSequence(options *SequenceOptions) (string, error) {
+	if len(p.loadedPackages) == 0 {
+		return "", fmt.Errorf("sequence diagrams need packages loaded in the type checked mode")
+	}
+	included, err := compilePackageFilters(options.Packages)
+	if err != nil {
+		return "", err
+	}
+	excluded, err := compilePackageFilters(options.ExcludedPackages)
+	if err != nil {
+		return "", err
+	}
+	s := &sequenceBuilder{
+		options:      options,
+		loaded:       make(map[string]bool),
+		included:     included,
+		excluded:     excluded,
+		participants: &LineStringBuilder{},
+		aliases:      make(map[string]string),
+		messages:     &LineStringBuilder{},
+		active:       make(map[*ssa.Function]bool),
+	}
+	for _, pkg := range p.loadedPackages {
+		s.loaded[pkg.PkgPath] = true
+	}
+	prog, _ := ssautil.AllPackages(p.loadedPackages, ssa.InstantiateGenerics)
+	entry, err := findEntryPoint(prog, p.loadedPackages, options.EntryPoint)
+	if err != nil {
+		return "", err
+	}
+	if err := s.build(prog, entry.Pkg); err != nil {
+		return "", err
+	}
+	s.graph = cha.CallGraph(prog)
+	from := s.participant(entry)
+	s.messages.WriteLineWithDepth(0, fmt.Sprintf("[-> %s : %s()", from, functionName(entry)))
+	s.messages.WriteLineWithDepth(0, fmt.Sprintf("activate %s", from))
+	s.active[entry] = true
+	s.calls(entry, from, 1)
+	s.messages.WriteLineWithDepth(0, fmt.Sprintf("deactivate %s", from))
+
+	str := &LineStringBuilder{}
+	str.WriteLineWithDepth(0, "@startuml")
+	p.renderStyle(str)
+	if p.renderingOptions.Title != "" {
+		str.WriteLineWithDepth(0, fmt.Sprintf(`title %s`, p.renderingOptions.Title))
+	}
+	if note := strings.TrimSpace(p.renderingOptions.Notes); note != "" {
+		str.WriteLineWithDepth(0, "legend")
+		str.WriteLineWithDepth(0, note)
+		str.WriteLineWithDepth(0, "end legend")
+	}
+	str.WriteString(s.participants.String())
+	str.WriteString(s.messages.String())
+	str.WriteLineWithDepth(0, "@enduml")
+	return str.String(), nil
+}
+
+//build builds the function bodies of the package of the entry point and of the shown packages only.
+//The calls of the other packages are not followed, and their bodies can be costly to build or use
+//syntax the SSA builder does not support yet.
+func (s *sequenceBuilder) build(prog *ssa.Program, entry *ssa.Package) (err error) {
+	for _, pkg := range prog.AllPackages() {
+		if pkg != entry && !s.shown(pkg.Pkg) {
+			continue
+		}
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					err = fmt.Errorf("failed to build the SSA form of %s: %v", pkg.Pkg.Path(), r)
+				}
+			}()
+			pkg.Build()
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func compilePackageFilters(filters []string) ([]*regexp.Regexp, error) {
+	var result []*regexp.Regexp
+	for _, filter := range filters {
+		reg, err := regexp.Compile(filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile package filter %s: %v", filter, err)
+		}
+		result = append(result, reg)
+	}
+	return result, nil
+}
+
+//findEntryPoint returns the function or method of the loaded packages named by entry
+func findEntryPoint(prog *ssa.Program, pkgs []*packages.Package, entry string) (*ssa.Function, error) {
+	var found []*ssa.Function
+	for _, pkg := range pkgs {
+		for _, prefix := range []string{pkg.PkgPath + ".", pkg.Name + "."} {
+			if strings.HasPrefix(entry, prefix) {
+				if fn := lookupFunction(prog, pkg.Types, entry[len(prefix):]); fn != nil {
+					found = append(found, fn)
+					break
+				}
+			}
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("failed to find %s in the loaded packages", entry)
+	case 1:
+		return found[0], nil
+	}
+	var names []string
+	for _, fn := range found {
+		names = append(names, fn.String())
+	}
+	return nil, fmt.Errorf("ambiguous entry point %s: %s", entry, strings.Join(names, ", "))
+}
+
+//lookupFunction returns the function of pkg named by name, which is Func, Type.Method or (*Type).Method
+func lookupFunction(prog *ssa.Program, pkg *types.Package, name string) *ssa.Function {
+	typeName, method, isMethod := strings.Cut(name, ".")
+	if !isMethod {
+		if obj, ok := pkg.Scope().Lookup(name).(*types.Func); ok {
+			return prog.FuncValue(obj)
+		}
+		return nil
+	}
+	if strings.HasPrefix(typeName, "(*") && strings.HasSuffix(typeName, ")") {
+		typeName = typeName[2 : len(typeName)-1]
+	}
+	obj, ok := pkg.Scope().Lookup(typeName).(*types.TypeName)
+	if !ok {
+		return nil
+	}
+	sel := prog.MethodSets.MethodSet(types.NewPointer(obj.Type())).Lookup(pkg, method)
+	if sel == nil {
+		return nil
+	}
+	return prog.FuncValue(sel.Obj().(*types.Func))
+}
+
+//calls writes the messages of the calls made by fn, which runs in the participant from
+func (s *sequenceBuilder) calls(fn *ssa.Function, from string, depth int) {
+	node := s.graph.Nodes[fn]
+	if node == nil {
+		return
+	}
+	callees := make(map[ssa.CallInstruction][]*ssa.Function)
+	for _, edge := range node.Out {
+		callees[edge.Site] = append(callees[edge.Site], edge.Callee.Func)
+	}
+	for _, block := range fn.Blocks {
+		for _, instr := range block.Instrs {
+			site, ok := instr.(ssa.CallInstruction)
+			if !ok {
+				continue
+			}
+			targets := callees[site]
+			if len(targets) == 1 {
+				s.call(site, targets[0], from, depth)
+			} else if site.Common().IsInvoke() {
+				s.invoke(site, from)
+			}
+		}
+	}
+}
+
+//call writes the message of a call with a single callee and follows it
+func (s *sequenceBuilder) call(site ssa.CallInstruction, callee *ssa.Function, from string, depth int) {
+	if callee.Parent() != nil {
+		// the calls of closures are shown as calls of the function they are declared in
+		if !s.active[callee] {
+			s.active[callee] = true
+			s.calls(callee, from, depth)
+			s.active[callee] = false
+		}
+		return
+	}
+	if !s.shown(functionPackage(callee)) {
+		return
+	}
+	to := s.participant(callee)
+	s.message(site, from, to, functionName(callee))
+	if (s.options.MaxDepth > 0 && depth >= s.options.MaxDepth) || s.active[callee] {
+		return
+	}
+	// the callee is only activated when it makes calls of its own
+	outer := s.messages
+	s.messages = &LineStringBuilder{}
+	s.active[callee] = true
+	s.calls(callee, to, depth+1)
+	s.active[callee] = false
+	nested := s.messages.String()
+	s.messages = outer
+	if nested != "" {
+		s.messages.WriteLineWithDepth(0, fmt.Sprintf("activate %s", to))
+		s.messages.WriteString(nested)
+		s.messages.WriteLineWithDepth(0, fmt.Sprintf("deactivate %s", to))
+	}
+}
+
+//invoke writes the message of a dynamically dispatched call to the interface it is made through
+func (s *sequenceBuilder) invoke(site ssa.CallInstruction, from string) {
+	named, ok := types.Unalias(site.Common().Value.Type()).(*types.Named)
+	if !ok || named.Obj().Pkg() == nil || !s.shown(named.Obj().Pkg()) {
+		return
+	}
+	obj := named.Origin().Obj()
+	to := s.alias(fmt.Sprintf("%s.%s", obj.Pkg().Path(), obj.Name()), fmt.Sprintf("%s.%s", obj.Pkg().Name(), obj.Name()), "interface")
+	s.message(site, from, to, site.Common().Method.Name())
+}
+
+func (s *sequenceBuilder) message(site ssa.CallInstruction, from, to, name string) {
+	switch site.(type) {
+	case *ssa.Go:
+		s.messages.WriteLineWithDepth(0, fmt.Sprintf("%s ->> %s : go %s()", from, to, name))
+	case *ssa.Defer:
+		s.messages.WriteLineWithDepth(0, fmt.Sprintf("%s -> %s : defer %s()", from, to, name))
+	default:
+		s.messages.WriteLineWithDepth(0, fmt.Sprintf("%s -> %s : %s()", from, to, name))
+	}
+}
+
+//shown returns true if the calls of the functions of pkg are part of the diagram
+func (s *sequenceBuilder) shown(pkg *types.Package) bool {
+	if pkg == nil {
+		return false
+	}
+	for _, reg := range s.excluded {
+		if reg.MatchString(pkg.Path()) {
+			return false
+		}
+	}
+	if s.loaded[pkg.Path()] {
+		return true
+	}
+	for _, reg := range s.included {
+		if reg.MatchString(pkg.Path()) {
+			return true
+		}
+	}
+	return false
+}
+
+//participant returns the alias of the participant a function runs in: the named type of its receiver,
+//or its package for plain functions
+func (s *sequenceBuilder) participant(fn *ssa.Function) string {
+	pkg := functionPackage(fn)
+	if recv := fn.Signature.Recv(); recv != nil {
+		t := recv.Type()
+		if ptr, ok := t.(*types.Pointer); ok {
+			t = ptr.Elem()
+		}
+		if named, ok := types.Unalias(t).(*types.Named); ok && named.Obj().Pkg() != nil {
+			obj := named.Origin().Obj()
+			return s.alias(fmt.Sprintf("%s.%s", obj.Pkg().Path(), obj.Name()), fmt.Sprintf("%s.%s", obj.Pkg().Name(), obj.Name()), "participant")
+		}
+	}
+	return s.alias(pkg.Path(), pkg.Name(), "participant")
+}
+
+//alias returns the alias of a participant, declaring it on first use
+func (s *sequenceBuilder) alias(key, display, kind string) string {
+	if alias, ok := s.aliases[key]; ok {
+		return alias
+	}
+	alias := fmt.Sprintf("p%d", len(s.aliases)+1)
+	s.aliases[key] = alias
+	s.participants.WriteLineWithDepth(0, fmt.Sprintf(`%s "%s" as %s`, kind, display, alias))
+	return alias
+}
+
+//functionPackage returns the package a function is declared in, also for the wrappers of methods
+func functionPackage(fn *ssa.Function) *types.Package {
+	if obj := fn.Object(); obj != nil {
+		return obj.Pkg()
+	}
+	if fn.Pkg != nil {
+		return fn.Pkg.Pkg
+	}
+	return nil
+}
+
+func functionName(fn *ssa.Function) string {
+	if origin := fn.Origin(); origin != nil {
+		fn = origin
+	}
+	return fn.Name()
+}
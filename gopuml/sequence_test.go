@@ -0,0 +1,131 @@
+package gopuml
+
+import "testing"
+
+var sequenceModule = map[string]string{
+	"go.mod": "module example.com/shop\n\ngo 1.21\n",
+	"shop.go": `package shop
+
+import "fmt"
+
+type Store interface {
+	Save(o *Order) error
+}
+
+type memory struct{}
+
+func (memory) Save(o *Order) error { return nil }
+
+type disk struct{}
+
+func (*disk) Save(o *Order) error { return nil }
+
+type Order struct {
+	Items []string
+}
+
+func (o *Order) Total() int { return o.count() }
+
+func (o *Order) count() int { return len(o.Items) }
+
+type Service struct {
+	store Store
+}
+
+func NewService() *Service { return &Service{store: memory{}} }
+
+func (s *Service) Checkout(o *Order) error {
+	if o.Total() == 0 {
+		return fmt.Errorf("empty order")
+	}
+	go s.notify(o)
+	return s.store.Save(o)
+}
+
+func (s *Service) notify(o *Order) {
+	func() {
+		s.notify(o)
+	}()
+}
+`,
+}
+
+func TestRenderSequence(t *testing.T) {
+	dir := writeTestModule(t, sequenceModule)
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{Directories: []string{dir}, TypeChecked: true})
+	if err != nil {
+		t.Fatalf("TestRenderSequence: expected no error, got %s", err)
+	}
+	result, err := parser.RenderSequence(&SequenceOptions{EntryPoint: "shop.(*Service).Checkout"})
+	if err != nil {
+		t.Fatalf("TestRenderSequence: expected no error, got %s", err)
+	}
+	expected := `@startuml
+participant "shop.Service" as p1
+participant "shop.Order" as p2
+interface "shop.Store" as p3
+[-> p1 : Checkout()
+activate p1
+p1 -> p2 : Total()
+activate p2
+p2 -> p2 : count()
+deactivate p2
+p1 ->> p1 : go notify()
+activate p1
+p1 -> p1 : notify()
+deactivate p1
+p1 -> p3 : Save()
+deactivate p1
+@enduml
+`
+	if result != expected {
+		t.Errorf("TestRenderSequence: expected\n%s\ngot\n%s", expected, result)
+	}
+
+	result, err = parser.RenderSequence(&SequenceOptions{
+		EntryPoint:       "example.com/shop.Service.Checkout",
+		MaxDepth:         1,
+		Packages:         []string{"^fmt$"},
+		ExcludedPackages: []string{"shop"},
+	})
+	if err != nil {
+		t.Fatalf("TestRenderSequence: expected no error, got %s", err)
+	}
+	// only the call of fmt.Errorf is left
+	expected = `@startuml
+participant "shop.Service" as p1
+participant "fmt" as p2
+[-> p1 : Checkout()
+activate p1
+p1 -> p2 : Errorf()
+deactivate p1
+@enduml
+`
+	if result != expected {
+		t.Errorf("TestRenderSequence: expected\n%s\ngot\n%s", expected, result)
+	}
+}
+
+func TestRenderSequenceErrors(t *testing.T) {
+	dir := writeTestModule(t, sequenceModule)
+	parser, err := NewClassDiagramWithOptions(&ClassDiagramOptions{Directories: []string{dir}, TypeChecked: true})
+	if err != nil {
+		t.Fatalf("TestRenderSequenceErrors: expected no error, got %s", err)
+	}
+	for _, entry := range []string{"shop.Missing", "shop.(*Service).Missing", "other.NewService"} {
+		if _, err := parser.RenderSequence(&SequenceOptions{EntryPoint: entry}); err == nil {
+			t.Errorf("TestRenderSequenceErrors: expected an error for %s", entry)
+		}
+	}
+	if _, err := parser.RenderSequence(&SequenceOptions{EntryPoint: "shop.NewService", Packages: []string{"("}}); err == nil {
+		t.Errorf("TestRenderSequenceErrors: expected an error for an invalid package filter")
+	}
+
+	astParser, err := NewClassDiagram([]string{dir}, nil, false)
+	if err != nil {
+		t.Fatalf("TestRenderSequenceErrors: expected no error, got %s", err)
+	}
+	if _, err := astParser.RenderSequence(&SequenceOptions{EntryPoint: "shop.NewService"}); err == nil {
+		t.Errorf("TestRenderSequenceErrors: expected an error without the type checked mode")
+	}
+}
@@ -2,6 +2,8 @@ package gopuml
 
 import (
 	"go/ast"
+	"strconv"
+	"strings"
 	"unicode"
 )
 
@@ -16,6 +18,10 @@ type Struct struct {
 	Extends             map[string]struct{}
 	Aggregations        map[string]struct{}
 	PrivateAggregations map[string]struct{}
+	TypeParams          []*Field
+	TypeSet             []string
+	// Doc is the doc comment of the type declaration
+	Doc string
 }
 
 // ImplementsInterface returns true if the struct st conforms ot the given interface
@@ -74,32 +80,70 @@ func (st *Struct) addToPrivateAggregation(fType string) {
 	st.PrivateAggregations[fType] = struct{}{}
 }
 
+//AddTypeParams adds the type parameters of a generic type declaration. The constraint of each
+//parameter is kept as its Type.
+func (st *Struct) AddTypeParams(params *ast.FieldList, aliases map[string]string) {
+	if params == nil {
+		return
+	}
+	for _, param := range params.List {
+		constraint, _ := getFieldType(param.Type, aliases)
+		for _, name := range param.Names {
+			st.TypeParams = append(st.TypeParams, &Field{
+				Name:     name.Name,
+				Type:     replacePackageConstant(constraint, ""),
+				FullType: replacePackageConstant(constraint, st.PackageName),
+			})
+		}
+	}
+}
+
+//isTypeParam returns true if the fundamental type t is one of the type parameters of the struct
+func (st *Struct) isTypeParam(t string) bool {
+	for _, param := range st.TypeParams {
+		if t == packageConstant+param.Name {
+			return true
+		}
+	}
+	return false
+}
+
 //AddField adds a field into this structure. It parses the ast.Field and extract all
 //needed information
 func (st *Struct) AddField(field *ast.Field, aliases map[string]string) {
 	theType, fundamentalTypes := getFieldType(field.Type, aliases)
 	theType = replacePackageConstant(theType, "")
+	if theType == "" {
+		return
+	}
 	if field.Names != nil {
 		theType = replacePackageConstant(theType, "")
 		newField := &Field{
 			Name: field.Names[0].Name,
 			Type: theType,
 		}
+		if field.Tag != nil {
+			newField.Tag, _ = strconv.Unquote(field.Tag.Value)
+		}
 		st.Fields = append(st.Fields, newField)
-		if unicode.IsUpper(rune(newField.Name[0])) {
-			for _, t := range fundamentalTypes {
-				st.AddToAggregation(replacePackageConstant(t, st.PackageName))
+		for _, t := range fundamentalTypes {
+			if st.isTypeParam(t) {
+				continue
 			}
-		} else {
-			for _, t := range fundamentalTypes {
+			if unicode.IsUpper(rune(newField.Name[0])) {
+				st.AddToAggregation(replacePackageConstant(t, st.PackageName))
+			} else {
 				st.addToPrivateAggregation(replacePackageConstant(t, st.PackageName))
 			}
 		}
 	} else if field.Type != nil {
-		if theType[0] == "*"[0] {
-			theType = theType[1:]
+		// an embedded instantiation is composed of its generic type, and an embedded type of another
+		// package is identified by its import path
+		composition := getGenericType(theType)
+		if strings.Contains(composition, ".") && len(fundamentalTypes) > 0 {
+			composition = fundamentalTypes[0]
 		}
-		st.AddToComposition(theType)
+		st.AddToComposition(composition)
 	}
 }
 
@@ -110,5 +154,6 @@ func (st *Struct) AddMethod(method *ast.Field, aliases map[string]string) {
 		return
 	}
 	function := getFunction(f, method.Names[0].Name, aliases, st.PackageName)
+	function.Doc = strings.TrimSpace(method.Doc.Text())
 	st.Functions = append(st.Functions, function)
 }
@@ -0,0 +1,198 @@
+package gopuml
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"sort"
+	"strings"
+)
+
+//creates is the label of the connections from the utility class of a package to the types its functions return
+const creates = "creates"
+
+//getOrCreateUtility returns the utility class of the current package, holding its package level functions,
+//variables and constants
+func (p *ClassParser) getOrCreateUtility() *Struct {
+	result, ok := p.utilities[p.currentPackageName]
+	if !ok {
+		result = &Struct{
+			PackageName:         p.currentPackageName,
+			Functions:           make([]*Function, 0),
+			Fields:              make([]*Field, 0),
+			Type:                "utility",
+			Composition:         make(map[string]struct{}, 0),
+			Extends:             make(map[string]struct{}, 0),
+			Aggregations:        make(map[string]struct{}, 0),
+			PrivateAggregations: make(map[string]struct{}, 0),
+		}
+		p.utilities[p.currentPackageName] = result
+	}
+	return result
+}
+
+//utilityName returns the name of the utility class of a package: the package name, unless a type of the
+//package already has it
+func (p *ClassParser) utilityName(pack string) string {
+	name := packageNameOf(pack)
+	for {
+		if _, ok := p.structure[pack][name]; !ok {
+			return name
+		}
+		name += "_"
+	}
+}
+
+//handleFreeFuncDecl adds a function without receiver to the utility class of its package, and records the
+//types of the package it returns as the types it creates
+func (p *ClassParser) handleFreeFuncDecl(decl *ast.FuncDecl) {
+	if decl.Name.Name == "init" || decl.Name.Name == "_" {
+		return
+	}
+	utility := p.getOrCreateUtility()
+	utility.AddMethod(&ast.Field{
+		Names: []*ast.Ident{decl.Name},
+		Doc:   decl.Doc,
+		Type:  decl.Type,
+	}, p.allImports)
+	if decl.Type.Results == nil {
+		return
+	}
+	if _, ok := p.creates[p.currentPackageName]; !ok {
+		p.creates[p.currentPackageName] = make(map[string]struct{})
+	}
+	for _, result := range decl.Type.Results.List {
+		_, fundamentalTypes := getFieldType(result.Type, p.allImports)
+		for _, t := range fundamentalTypes {
+			p.creates[p.currentPackageName][replacePackageConstant(t, p.currentPackageName)] = struct{}{}
+		}
+	}
+}
+
+//handleVarDecl adds the package level variables of a declaration to the utility class of their package
+func (p *ClassParser) handleVarDecl(decl *ast.GenDecl) {
+	for _, spec := range decl.Specs {
+		v, ok := spec.(*ast.ValueSpec)
+		if !ok {
+			continue
+		}
+		for i, name := range v.Names {
+			if name.Name == "_" {
+				continue
+			}
+			utility := p.getOrCreateUtility()
+			utility.Fields = append(utility.Fields, &Field{Name: name.Name, Type: p.valueType(name, v.Type, v.Values, i)})
+		}
+	}
+}
+
+//addUtilityConstant adds a package level constant to the utility class of its package, e.g. "Idle State = 0"
+func (p *ClassParser) addUtilityConstant(name *ast.Ident, typ ast.Expr, c *Constant) {
+	field := &Field{Name: name.Name, Type: p.valueType(name, typ, nil, 0)}
+	if c.Value != "" {
+		field.Type = strings.TrimSpace(fmt.Sprintf("%s = %s", field.Type, c.Value))
+	}
+	utility := p.getOrCreateUtility()
+	utility.Fields = append(utility.Fields, field)
+}
+
+//valueType returns the type of a package level variable or constant: the declared type, the type go/types
+//inferred in type checked mode, or the type of a composite literal value
+func (p *ClassParser) valueType(name *ast.Ident, typ ast.Expr, values []ast.Expr, i int) string {
+	if typ != nil {
+		t, _ := getFieldType(typ, p.allImports)
+		return replacePackageConstant(t, "")
+	}
+	if p.typeInfo != nil {
+		if obj := p.typeInfo.Defs[name]; obj != nil {
+			if basic, ok := obj.Type().(*types.Basic); ok && basic.Info()&types.IsUntyped != 0 {
+				return ""
+			}
+			return types.TypeString(obj.Type(), func(pkg *types.Package) string {
+				if pkg.Path() == p.currentPackageName {
+					return ""
+				}
+				return pkg.Name()
+			})
+		}
+	}
+	if i >= len(values) {
+		return ""
+	}
+	value := values[i]
+	pointer := ""
+	if unary, ok := value.(*ast.UnaryExpr); ok {
+		value = unary.X
+		pointer = "*"
+	}
+	if lit, ok := value.(*ast.CompositeLit); ok && lit.Type != nil {
+		t, _ := getFieldType(lit.Type, p.allImports)
+		return pointer + replacePackageConstant(t, "")
+	}
+	return ""
+}
+
+//renderUtility writes the utility class of a package and returns the connections to the types it creates
+func (p *ClassParser) renderUtility(pack string, str *LineStringBuilder) []string {
+	if !p.hasUtility(pack, p.visible) {
+		return nil
+	}
+	utility := p.utilities[pack]
+	name := p.utilityName(pack)
+	id := diagramID(pack, name)
+	privateFields := &LineStringBuilder{}
+	publicFields := &LineStringBuilder{}
+	privateMethods := &LineStringBuilder{}
+	publicMethods := &LineStringBuilder{}
+	p.renderStructFields(utility, privateFields, publicFields)
+	p.renderMethods(utility, "", privateMethods, publicMethods)
+	str.WriteLineWithDepth(1, fmt.Sprintf(`class %s << (U,Orchid) utility >>%s {`, name, p.diffTypeColor(utility)))
+	for _, members := range []*LineStringBuilder{privateFields, publicFields, privateMethods, publicMethods} {
+		if members.Len() > 0 {
+			str.WriteLineWithDepth(0, members.String())
+		}
+	}
+	str.WriteLineWithDepth(1, "}")
+
+	var connections []string
+	for _, t := range p.createdTypes(pack) {
+		if isVisible(p.visible, t) {
+			connections = append(connections, fmt.Sprintf(`"%s" ..> "%s" : %s`, p.renderName(id), p.renderName(t), creates))
+		}
+	}
+	return connections
+}
+
+//hasUtility returns true if the utility class of a package is rendered with the visible types
+func (p *ClassParser) hasUtility(pack string, visible map[string]bool) bool {
+	_, ok := p.utilities[pack]
+	return ok && p.renderingOptions.Utilities && isVisible(visible, diagramID(pack, p.utilityName(pack)))
+}
+
+//createdTypes returns the parsed types the package level functions of a package return, sorted
+func (p *ClassParser) createdTypes(pack string) []string {
+	var result []string
+	for t := range p.creates[pack] {
+		// named types that are not structs or interfaces are kept under their qualified name
+		typePackage, _ := p.splitName(t)
+		if _, ok := p.structure[typePackage][t]; ok || p.getStruct(t) != nil {
+			result = append(result, t)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+//utilityRelations returns the connections from the utility classes to the types they create
+func (p *ClassParser) utilityRelations() []*diagramRelation {
+	if !p.renderingOptions.Utilities {
+		return nil
+	}
+	var relations []*diagramRelation
+	for _, pack := range p.parsedPackages() {
+		for _, t := range p.createdTypes(pack) {
+			relations = append(relations, &diagramRelation{Kind: relationCreates, From: diagramID(pack, p.utilityName(pack)), To: t})
+		}
+	}
+	return relations
+}
@@ -0,0 +1,158 @@
+package gopuml
+
+import "testing"
+
+var utilityModule = map[string]string{
+	"go.mod": "module example.com/client\n",
+	"client.go": `package client
+
+import "time"
+
+const DefaultTimeout = 5 * time.Second
+
+const retries = 3
+
+var DefaultClient = &Client{}
+
+type Client struct{}
+
+type Opt func(*Client)
+
+func New(opts ...Opt) (*Client, error) { return nil, nil }
+
+func WithTimeout(d time.Duration) Opt { return nil }
+
+func parse(b []byte) int { return 0 }
+
+func init() {}
+`,
+	"empty/empty.go": "package empty\n\nfunc Helper() string { return \"\" }\n",
+}
+
+func TestUtilities(t *testing.T) {
+	dir := writeTestModule(t, utilityModule)
+	parser, err := NewClassDiagram([]string{dir}, nil, true)
+	if err != nil {
+		t.Fatalf("TestUtilities: expected no error, got %s", err)
+	}
+	expected := `@startuml
+left to right direction
+namespace example.com.client {
+    class Client << (S,Aquamarine) >> {
+    }
+    class example.com.client.Opt << (T, #FF7700) >>  {
+    }
+}
+
+
+"example.com.client.<font color=blue>func</font>(*Client) " #.. "example.com.client.Opt"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestUtilities: expected without the option\n%s\ngot\n%s", expected, result)
+	}
+	if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderUtilities: true}); err != nil {
+		t.Fatalf("TestUtilities: expected no error, got %s", err)
+	}
+	// init functions are left out
+	expected = `@startuml
+left to right direction
+namespace example.com.client {
+    class Client << (S,Aquamarine) >> {
+    }
+    class example.com.client.Opt << (T, #FF7700) >>  {
+    }
+    class client << (U,Orchid) utility >> {
+        - retries = 3
+
+        + DefaultTimeout = 5 * time.Second
+        + DefaultClient *Client
+
+        - parse(b []byte) int
+
+        + New(opts ...Opt) (*Client, error)
+        + WithTimeout(d time.Duration) Opt
+
+    }
+}
+
+
+"example.com.client.client" ..> "example.com.client.Client" : creates
+"example.com.client.client" ..> "example.com.client.Opt" : creates
+namespace example.com.client.empty {
+    class empty << (U,Orchid) utility >> {
+        + Helper() string
+
+    }
+}
+
+
+"example.com.client.<font color=blue>func</font>(*Client) " #.. "example.com.client.Opt"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestUtilities: expected\n%s\ngot\n%s", expected, result)
+	}
+	expected = `classDiagram
+    direction LR
+    namespace example_com_client {
+        class example_com_client_Client["Client"]
+        class example_com_client_Opt["Opt"]
+        class example_com_client_client["client"]
+    }
+    namespace example_com_client_empty {
+        class example_com_client_empty_empty["empty"]
+    }
+    class example_com_client_func__Client_["example.com.client.func(*Client)"]
+    <<alias>> example_com_client_Opt
+    <<utility>> example_com_client_client
+    example_com_client_client : -retries = 3
+    example_com_client_client : +DefaultTimeout = 5 * time.Second
+    example_com_client_client : +DefaultClient *Client
+    example_com_client_client : -parse(b []byte) int
+    example_com_client_client : +New(opts ...Opt) (*Client, error)
+    example_com_client_client : +WithTimeout(d time.Duration) Opt
+    <<utility>> example_com_client_empty_empty
+    example_com_client_empty_empty : +Helper() string
+    example_com_client_func__Client_ .. example_com_client_Opt
+    example_com_client_client ..> example_com_client_Client : creates
+    example_com_client_client ..> example_com_client_Opt : creates
+`
+	if result := (MermaidRenderer{}).Render(parser); result != expected {
+		t.Errorf("TestUtilities: expected the Mermaid diagram\n%s\ngot\n%s", expected, result)
+	}
+
+	if err := parser.SetRenderingOptions(map[RenderingOption]interface{}{RenderExclude: []string{`empty`}}); err != nil {
+		t.Fatalf("TestUtilities: expected no error, got %s", err)
+	}
+	expected = `@startuml
+left to right direction
+namespace example.com.client {
+    class Client << (S,Aquamarine) >> {
+    }
+    class example.com.client.Opt << (T, #FF7700) >>  {
+    }
+    class client << (U,Orchid) utility >> {
+        - retries = 3
+
+        + DefaultTimeout = 5 * time.Second
+        + DefaultClient *Client
+
+        - parse(b []byte) int
+
+        + New(opts ...Opt) (*Client, error)
+        + WithTimeout(d time.Duration) Opt
+
+    }
+}
+
+
+"example.com.client.client" ..> "example.com.client.Client" : creates
+"example.com.client.client" ..> "example.com.client.Opt" : creates
+"example.com.client.<font color=blue>func</font>(*Client) " #.. "example.com.client.Opt"
+@enduml
+`
+	if result := parser.Render(); result != expected {
+		t.Errorf("TestUtilities: expected without the excluded package\n%s\ngot\n%s", expected, result)
+	}
+}